@@ -0,0 +1,114 @@
+// Licensed to Alexandre VILAIN under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Alexandre VILAIN licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package builders
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alexandrevilain/controller-tools/pkg/resource"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// NewDeployment returns a builder of Deployments.
+// Supported options are WithReplicas, WithSelector and WithPodTemplate.
+func NewDeployment(name, namespace string, opts ...Option) resource.Builder {
+	return resource.NewBuilder(New(name, namespace, func() *appsv1.Deployment { return &appsv1.Deployment{} }, opts...))
+}
+
+// WithReplicas allows to define the number of replicas of a Deployment.
+// Use WithIgnoredFields("spec.replicas") instead when replicas are managed by an HorizontalPodAutoscaler.
+type WithReplicas int32
+
+// ApplyToBuilder applies this configuration to the given builder options.
+func (w WithReplicas) ApplyToBuilder(in *Options) {
+	in.Mutators = append(in.Mutators, func(object client.Object) error {
+		deploy, ok := object.(*appsv1.Deployment)
+		if !ok {
+			return newUnsupportedOptionError(w, object)
+		}
+
+		deploy.Spec.Replicas = ptr.To(int32(w))
+		return nil
+	})
+}
+
+// WithPodTemplate allows to define the pod template of a Deployment.
+// Template fields defaulted by the api server keep their live value when left unset.
+// Template labels and annotations are merged with the live ones, so that those set by other tools,
+// like the restart annotation of kubectl rollout restart, are kept.
+type WithPodTemplate corev1.PodTemplateSpec
+
+// ApplyToBuilder applies this configuration to the given builder options.
+func (w WithPodTemplate) ApplyToBuilder(in *Options) {
+	in.Mutators = append(in.Mutators, func(object client.Object) error {
+		deploy, ok := object.(*appsv1.Deployment)
+		if !ok {
+			return newUnsupportedOptionError(w, object)
+		}
+
+		current := deploy.DeepCopy()
+		template := corev1.PodTemplateSpec(w)
+		deploy.Spec.Template = *template.DeepCopy()
+		deploy.Spec.Template.Labels = mergeMaps(current.Spec.Template.Labels, template.Labels)
+		deploy.Spec.Template.Annotations = mergeMaps(current.Spec.Template.Annotations, template.Annotations)
+
+		err := resource.PreserveUnsetFields(current, deploy, podTemplateDefaultedFields...)
+		if err != nil {
+			return fmt.Errorf("can't preserve pod template defaults: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// podTemplateDefaultedFields are the Deployment's pod template fields defaulted by the api server.
+var podTemplateDefaultedFields = func() []string {
+	fields := []string{}
+	for _, field := range resource.ServerDefaultedFields[appsv1.SchemeGroupVersion.WithKind("Deployment").GroupKind()] {
+		if strings.HasPrefix(field, "spec.template.") {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}()
+
+// WithSelector allows to define the labels selecting pods of a Deployment, a Service or a PodDisruptionBudget.
+type WithSelector map[string]string
+
+// ApplyToBuilder applies this configuration to the given builder options.
+func (w WithSelector) ApplyToBuilder(in *Options) {
+	in.Mutators = append(in.Mutators, func(object client.Object) error {
+		switch o := object.(type) {
+		case *appsv1.Deployment:
+			o.Spec.Selector = &metav1.LabelSelector{MatchLabels: copyMap(w)}
+		case *corev1.Service:
+			o.Spec.Selector = copyMap(w)
+		case *policyv1.PodDisruptionBudget:
+			o.Spec.Selector = &metav1.LabelSelector{MatchLabels: copyMap(w)}
+		default:
+			return newUnsupportedOptionError(w, object)
+		}
+		return nil
+	})
+}
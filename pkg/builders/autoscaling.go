@@ -0,0 +1,99 @@
+// Licensed to Alexandre VILAIN under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Alexandre VILAIN licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package builders
+
+import (
+	"github.com/alexandrevilain/controller-tools/pkg/resource"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// NewHorizontalPodAutoscaler returns a builder of HorizontalPodAutoscalers.
+// Supported options are WithScaleTargetRef, WithMinReplicas, WithMaxReplicas and WithMetrics.
+func NewHorizontalPodAutoscaler(name, namespace string, opts ...Option) resource.Builder {
+	return resource.NewBuilder(New(name, namespace, func() *autoscalingv2.HorizontalPodAutoscaler { return &autoscalingv2.HorizontalPodAutoscaler{} }, opts...))
+}
+
+// WithScaleTargetRef allows to define the resource scaled by an HorizontalPodAutoscaler.
+type WithScaleTargetRef autoscalingv2.CrossVersionObjectReference
+
+// ApplyToBuilder applies this configuration to the given builder options.
+func (w WithScaleTargetRef) ApplyToBuilder(in *Options) {
+	in.Mutators = append(in.Mutators, func(object client.Object) error {
+		hpa, ok := object.(*autoscalingv2.HorizontalPodAutoscaler)
+		if !ok {
+			return newUnsupportedOptionError(w, object)
+		}
+
+		hpa.Spec.ScaleTargetRef = autoscalingv2.CrossVersionObjectReference(w)
+		return nil
+	})
+}
+
+// WithMinReplicas allows to define the minimum number of replicas of an HorizontalPodAutoscaler.
+type WithMinReplicas int32
+
+// ApplyToBuilder applies this configuration to the given builder options.
+func (w WithMinReplicas) ApplyToBuilder(in *Options) {
+	in.Mutators = append(in.Mutators, func(object client.Object) error {
+		hpa, ok := object.(*autoscalingv2.HorizontalPodAutoscaler)
+		if !ok {
+			return newUnsupportedOptionError(w, object)
+		}
+
+		hpa.Spec.MinReplicas = ptr.To(int32(w))
+		return nil
+	})
+}
+
+// WithMaxReplicas allows to define the maximum number of replicas of an HorizontalPodAutoscaler.
+type WithMaxReplicas int32
+
+// ApplyToBuilder applies this configuration to the given builder options.
+func (w WithMaxReplicas) ApplyToBuilder(in *Options) {
+	in.Mutators = append(in.Mutators, func(object client.Object) error {
+		hpa, ok := object.(*autoscalingv2.HorizontalPodAutoscaler)
+		if !ok {
+			return newUnsupportedOptionError(w, object)
+		}
+
+		hpa.Spec.MaxReplicas = int32(w)
+		return nil
+	})
+}
+
+// WithMetrics allows to define the metrics used by an HorizontalPodAutoscaler.
+type WithMetrics []autoscalingv2.MetricSpec
+
+// ApplyToBuilder applies this configuration to the given builder options.
+func (w WithMetrics) ApplyToBuilder(in *Options) {
+	in.Mutators = append(in.Mutators, func(object client.Object) error {
+		hpa, ok := object.(*autoscalingv2.HorizontalPodAutoscaler)
+		if !ok {
+			return newUnsupportedOptionError(w, object)
+		}
+
+		metrics := make([]autoscalingv2.MetricSpec, 0, len(w))
+		for _, metric := range w {
+			metrics = append(metrics, *metric.DeepCopy())
+		}
+		hpa.Spec.Metrics = metrics
+		return nil
+	})
+}
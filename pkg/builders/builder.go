@@ -0,0 +1,110 @@
+// Licensed to Alexandre VILAIN under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Alexandre VILAIN licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package builders provides reusable resource builders for common kubernetes kinds.
+// Builders are configured using options and only manage the fields set by their options,
+// leaving other fields to the api server and other controllers.
+package builders
+
+import (
+	"github.com/alexandrevilain/controller-tools/pkg/resource"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Builder is a resource.TypedBuilder configured using options.
+// Use resource.NewBuilder to get a resource.Builder from it.
+type Builder[T client.Object] struct {
+	name      string
+	namespace string
+	newObject func() T
+	options   Options
+}
+
+var _ resource.TypedBuilder[client.Object] = &Builder[client.Object]{}
+
+// New returns a builder of objects of type T, named using the provided name and namespace.
+// newObject must return a new empty object of type T.
+func New[T client.Object](name, namespace string, newObject func() T, opts ...Option) *Builder[T] {
+	options := Options{
+		Enabled: true,
+	}
+	for _, opt := range opts {
+		opt.ApplyToBuilder(&options)
+	}
+
+	return &Builder[T]{
+		name:      name,
+		namespace: namespace,
+		newObject: newObject,
+		options:   options,
+	}
+}
+
+// Build returns a new object with its name and namespace.
+func (b *Builder[T]) Build() T {
+	object := b.newObject()
+	object.SetName(b.name)
+	object.SetNamespace(b.namespace)
+	return object
+}
+
+// Enabled returns whenever the builder is enabled.
+func (b *Builder[T]) Enabled() bool {
+	return b.options.Enabled
+}
+
+// Update sets the fields managed by the builder's options on the provided object.
+// Labels and annotations are merged with the object's ones, other fields are left untouched.
+func (b *Builder[T]) Update(object T) error {
+	object.SetLabels(mergeMaps(object.GetLabels(), b.options.Labels))
+	object.SetAnnotations(mergeMaps(object.GetAnnotations(), b.options.Annotations))
+
+	for _, mutate := range b.options.Mutators {
+		err := mutate(object)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Dependencies returns the dependencies provided using WithDependencies.
+func (b *Builder[T]) Dependencies() []resource.Dependency {
+	return b.options.Dependencies
+}
+
+// IgnoredFields returns the ignored fields provided using WithIgnoredFields.
+func (b *Builder[T]) IgnoredFields() []string {
+	return b.options.IgnoredFields
+}
+
+// mergeMaps returns the current map updated with the desired entries.
+func mergeMaps(current, desired map[string]string) map[string]string {
+	if len(desired) == 0 {
+		return current
+	}
+
+	result := make(map[string]string, len(current)+len(desired))
+	for key, value := range current {
+		result[key] = value
+	}
+	for key, value := range desired {
+		result[key] = value
+	}
+	return result
+}
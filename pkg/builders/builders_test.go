@@ -0,0 +1,336 @@
+// Licensed to Alexandre VILAIN under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Alexandre VILAIN licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package builders_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/alexandrevilain/controller-tools/pkg/builders"
+	"github.com/alexandrevilain/controller-tools/pkg/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestBuilders(t *testing.T) {
+	selector := map[string]string{"app": "test"}
+	objectMeta := metav1.ObjectMeta{Name: "test", Namespace: "default"}
+
+	tests := map[string]struct {
+		builder  resource.Builder
+		expected client.Object
+	}{
+		"deployment": {
+			builder: builders.NewDeployment("test", "default",
+				builders.WithReplicas(2),
+				builders.WithSelector(selector),
+				builders.WithPodTemplate(corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: selector},
+				}),
+			),
+			expected: &appsv1.Deployment{
+				ObjectMeta: objectMeta,
+				Spec: appsv1.DeploymentSpec{
+					Replicas: ptr.To[int32](2),
+					Selector: &metav1.LabelSelector{MatchLabels: selector},
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{Labels: selector},
+					},
+				},
+			},
+		},
+		"service": {
+			builder: builders.NewService("test", "default",
+				builders.WithSelector(selector),
+				builders.WithPorts{{Name: "http", Port: 80}},
+				builders.WithServiceType(corev1.ServiceTypeClusterIP),
+			),
+			expected: &corev1.Service{
+				ObjectMeta: objectMeta,
+				Spec: corev1.ServiceSpec{
+					Selector: selector,
+					Ports:    []corev1.ServicePort{{Name: "http", Port: 80, Protocol: corev1.ProtocolTCP, TargetPort: intstr.FromInt32(80)}},
+					Type:     corev1.ServiceTypeClusterIP,
+				},
+			},
+		},
+		"configmap": {
+			builder: builders.NewConfigMap("test", "default",
+				builders.WithData{"key": "value"},
+				builders.WithBinaryData{"binary": []byte("value")},
+			),
+			expected: &corev1.ConfigMap{
+				ObjectMeta: objectMeta,
+				Data:       map[string]string{"key": "value"},
+				BinaryData: map[string][]byte{"binary": []byte("value")},
+			},
+		},
+		"secret": {
+			builder: builders.NewSecret("test", "default",
+				builders.WithData{"key": "value"},
+				builders.WithSecretType(corev1.SecretTypeOpaque),
+			),
+			expected: &corev1.Secret{
+				ObjectMeta: objectMeta,
+				Data:       map[string][]byte{"key": []byte("value")},
+				Type:       corev1.SecretTypeOpaque,
+			},
+		},
+		"service account": {
+			builder: builders.NewServiceAccount("test", "default",
+				builders.WithAutomountServiceAccountToken(false),
+			),
+			expected: &corev1.ServiceAccount{
+				ObjectMeta:                   objectMeta,
+				AutomountServiceAccountToken: ptr.To(false),
+			},
+		},
+		"role": {
+			builder: builders.NewRole("test", "default",
+				builders.WithRules{{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}}},
+			),
+			expected: &rbacv1.Role{
+				ObjectMeta: objectMeta,
+				Rules:      []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}}},
+			},
+		},
+		"cluster role binding": {
+			builder: builders.NewClusterRoleBinding("test",
+				builders.WithRoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "test"},
+				builders.WithSubjects{{Kind: rbacv1.ServiceAccountKind, Name: "test", Namespace: "default"}},
+			),
+			expected: &rbacv1.ClusterRoleBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+				RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "test"},
+				Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "test", Namespace: "default"}},
+			},
+		},
+		"pod disruption budget": {
+			builder: builders.NewPodDisruptionBudget("test", "default",
+				builders.WithSelector(selector),
+				builders.WithMaxUnavailable(intstr.FromInt32(1)),
+			),
+			expected: &policyv1.PodDisruptionBudget{
+				ObjectMeta: objectMeta,
+				Spec: policyv1.PodDisruptionBudgetSpec{
+					Selector:       &metav1.LabelSelector{MatchLabels: selector},
+					MaxUnavailable: ptr.To(intstr.FromInt32(1)),
+				},
+			},
+		},
+		"horizontal pod autoscaler": {
+			builder: builders.NewHorizontalPodAutoscaler("test", "default",
+				builders.WithScaleTargetRef{APIVersion: "apps/v1", Kind: "Deployment", Name: "test"},
+				builders.WithMinReplicas(1),
+				builders.WithMaxReplicas(3),
+			),
+			expected: &autoscalingv2.HorizontalPodAutoscaler{
+				ObjectMeta: objectMeta,
+				Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+					ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "test"},
+					MinReplicas:    ptr.To[int32](1),
+					MaxReplicas:    3,
+				},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(tt *testing.T) {
+			assert.True(tt, test.builder.Enabled())
+
+			object := test.builder.Build()
+			require.NoError(tt, test.builder.Update(object))
+			assert.Equal(tt, test.expected, object)
+		})
+	}
+}
+
+func TestBuilderOnlyManagesItsFields(t *testing.T) {
+	builder := builders.NewDeployment("test", "default",
+		builders.WithLabels{"app": "test"},
+		builders.WithAnnotations{"description": "test"},
+		builders.WithReplicas(2),
+	)
+
+	deploy := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test",
+			Namespace:   "default",
+			Labels:      map[string]string{"app": "old", "external": "true"},
+			Annotations: map[string]string{"external": "true"},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas:        ptr.To[int32](1),
+			MinReadySeconds: 10,
+		},
+	}
+	require.NoError(t, builder.Update(deploy))
+
+	assert.Equal(t, map[string]string{"app": "test", "external": "true"}, deploy.Labels)
+	assert.Equal(t, map[string]string{"description": "test", "external": "true"}, deploy.Annotations)
+	assert.Equal(t, int32(2), *deploy.Spec.Replicas)
+	assert.Equal(t, int32(10), deploy.Spec.MinReadySeconds)
+}
+
+func TestPodTemplateKeepsServerDefaults(t *testing.T) {
+	builder := builders.NewDeployment("test", "default",
+		builders.WithPodTemplate(corev1.PodTemplateSpec{
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "app", Image: "nginx:1.27"}},
+			},
+		}),
+	)
+
+	deploy := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyAlways,
+					Containers: []corev1.Container{
+						{Name: "app", Image: "nginx:1.26", ImagePullPolicy: corev1.PullIfNotPresent},
+						{Name: "removed", Image: "busybox"},
+					},
+				},
+			},
+		},
+	}
+	require.NoError(t, builder.Update(deploy))
+
+	assert.Equal(t, corev1.PodSpec{
+		RestartPolicy: corev1.RestartPolicyAlways,
+		Containers:    []corev1.Container{{Name: "app", Image: "nginx:1.27", ImagePullPolicy: corev1.PullIfNotPresent}},
+	}, deploy.Spec.Template.Spec)
+}
+
+func TestPodTemplateKeepsExternalMetadata(t *testing.T) {
+	builder := builders.NewDeployment("test", "default",
+		builders.WithPodTemplate(corev1.PodTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels:      map[string]string{"app": "test"},
+				Annotations: map[string]string{"description": "test"},
+			},
+		}),
+	)
+
+	deploy := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      map[string]string{"app": "old", "external": "true"},
+					Annotations: map[string]string{"kubectl.kubernetes.io/restartedAt": "2024-01-01T00:00:00Z"},
+				},
+			},
+		},
+	}
+	require.NoError(t, builder.Update(deploy))
+
+	assert.Equal(t, map[string]string{"app": "test", "external": "true"}, deploy.Spec.Template.Labels)
+	assert.Equal(t, map[string]string{
+		"description":                       "test",
+		"kubectl.kubernetes.io/restartedAt": "2024-01-01T00:00:00Z",
+	}, deploy.Spec.Template.Annotations)
+}
+
+func TestPortsKeepAllocatedNodePorts(t *testing.T) {
+	builder := builders.NewService("test", "default",
+		builders.WithServiceType(corev1.ServiceTypeNodePort),
+		builders.WithPorts{{Name: "http", Port: 80}, {Port: 443}, {Name: "metrics", Port: 9090, NodePort: 30090}},
+	)
+
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{
+				{Name: "http", Port: 8080, Protocol: corev1.ProtocolTCP, NodePort: 30080},
+				{Port: 443, Protocol: corev1.ProtocolTCP, NodePort: 30443},
+				{Name: "metrics", Port: 9090, Protocol: corev1.ProtocolTCP, NodePort: 30000},
+			},
+		},
+	}
+	require.NoError(t, builder.Update(service))
+
+	nodePorts := []int32{}
+	for _, port := range service.Spec.Ports {
+		nodePorts = append(nodePorts, port.NodePort)
+	}
+	assert.Equal(t, []int32{30080, 30443, 30090}, nodePorts)
+}
+
+func TestBuilderOptions(t *testing.T) {
+	dependencies := []resource.Dependency{{Object: &corev1.ConfigMap{}, Name: "test", Namespace: "default"}}
+	builder := builders.NewDeployment("test", "default",
+		builders.WithEnabled(false),
+		builders.WithDependencies(dependencies),
+		builders.WithIgnoredFields{"spec.replicas"},
+		builders.Mutate(func(deploy *appsv1.Deployment) error {
+			deploy.Spec.MinReadySeconds = 10
+			return nil
+		}),
+	)
+
+	assert.False(t, builder.Enabled())
+	require.Implements(t, (*resource.Dependent)(nil), builder)
+	assert.Equal(t, dependencies, builder.(resource.Dependent).Dependencies())
+	require.Implements(t, (*resource.FieldIgnorer)(nil), builder)
+	assert.Equal(t, []string{"spec.replicas"}, builder.(resource.FieldIgnorer).IgnoredFields())
+
+	deploy := builder.Build()
+	require.NoError(t, builder.Update(deploy))
+	assert.Equal(t, int32(10), deploy.(*appsv1.Deployment).Spec.MinReadySeconds)
+}
+
+func TestBuilderErrors(t *testing.T) {
+	tests := map[string]struct {
+		option      builders.Option
+		expectedErr string
+	}{
+		"unsupported option": {
+			option:      builders.WithReplicas(1),
+			expectedErr: "option builders.WithReplicas doesn't support *v1.ConfigMap objects",
+		},
+		"mutate type mismatch": {
+			option:      builders.Mutate(func(*appsv1.Deployment) error { return nil }),
+			expectedErr: "unexpected object type: expected *v1.Deployment, got *v1.ConfigMap",
+		},
+		"mutate error": {
+			option:      builders.Mutate(func(*corev1.ConfigMap) error { return errors.New("mutate error") }),
+			expectedErr: "mutate error",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(tt *testing.T) {
+			builder := builders.NewConfigMap("test", "default", test.option)
+
+			err := builder.Update(builder.Build())
+			assert.EqualError(tt, err, test.expectedErr)
+		})
+	}
+}
@@ -0,0 +1,201 @@
+// Licensed to Alexandre VILAIN under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Alexandre VILAIN licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package builders
+
+import (
+	"github.com/alexandrevilain/controller-tools/pkg/resource"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// NewService returns a builder of Services.
+// Supported options are WithSelector, WithPorts and WithServiceType.
+func NewService(name, namespace string, opts ...Option) resource.Builder {
+	return resource.NewBuilder(New(name, namespace, func() *corev1.Service { return &corev1.Service{} }, opts...))
+}
+
+// NewConfigMap returns a builder of ConfigMaps.
+// Supported options are WithData and WithBinaryData.
+func NewConfigMap(name, namespace string, opts ...Option) resource.Builder {
+	return resource.NewBuilder(New(name, namespace, func() *corev1.ConfigMap { return &corev1.ConfigMap{} }, opts...))
+}
+
+// NewSecret returns a builder of Secrets.
+// Supported options are WithData, WithBinaryData and WithSecretType.
+func NewSecret(name, namespace string, opts ...Option) resource.Builder {
+	return resource.NewBuilder(New(name, namespace, func() *corev1.Secret { return &corev1.Secret{} }, opts...))
+}
+
+// NewServiceAccount returns a builder of ServiceAccounts.
+// Supported option is WithAutomountServiceAccountToken.
+func NewServiceAccount(name, namespace string, opts ...Option) resource.Builder {
+	return resource.NewBuilder(New(name, namespace, func() *corev1.ServiceAccount { return &corev1.ServiceAccount{} }, opts...))
+}
+
+// WithPorts allows to define the ports of a Service.
+// Ports protocol and target port are defaulted like the api server does,
+// so that the Service isn't updated because of an unset target port.
+// Unset node ports keep the value allocated by the api server.
+type WithPorts []corev1.ServicePort
+
+// ApplyToBuilder applies this configuration to the given builder options.
+func (w WithPorts) ApplyToBuilder(in *Options) {
+	in.Mutators = append(in.Mutators, func(object client.Object) error {
+		service, ok := object.(*corev1.Service)
+		if !ok {
+			return newUnsupportedOptionError(w, object)
+		}
+
+		ports := make([]corev1.ServicePort, 0, len(w))
+		for _, port := range w {
+			if port.Protocol == "" {
+				port.Protocol = corev1.ProtocolTCP
+			}
+			if port.TargetPort == (intstr.IntOrString{}) {
+				port.TargetPort = intstr.FromInt32(port.Port)
+			}
+			if live, found := findServicePort(service.Spec.Ports, port); found && port.NodePort == 0 {
+				port.NodePort = live.NodePort
+			}
+			ports = append(ports, port)
+		}
+		service.Spec.Ports = ports
+		return nil
+	})
+}
+
+// findServicePort returns the port of the provided ports matching port by name, or by port number if it has no name.
+func findServicePort(ports []corev1.ServicePort, port corev1.ServicePort) (corev1.ServicePort, bool) {
+	for _, candidate := range ports {
+		if port.Name != "" && candidate.Name == port.Name {
+			return candidate, true
+		}
+	}
+
+	for _, candidate := range ports {
+		if candidate.Port == port.Port && candidate.Protocol == port.Protocol {
+			return candidate, true
+		}
+	}
+
+	return corev1.ServicePort{}, false
+}
+
+// WithServiceType allows to define the type of a Service.
+type WithServiceType corev1.ServiceType
+
+// ApplyToBuilder applies this configuration to the given builder options.
+func (w WithServiceType) ApplyToBuilder(in *Options) {
+	in.Mutators = append(in.Mutators, func(object client.Object) error {
+		service, ok := object.(*corev1.Service)
+		if !ok {
+			return newUnsupportedOptionError(w, object)
+		}
+
+		service.Spec.Type = corev1.ServiceType(w)
+		return nil
+	})
+}
+
+// WithData allows to define the data of a ConfigMap or a Secret.
+// Secret values are stored in the secret's data, not its string data, so that they can be compared with the live object.
+type WithData map[string]string
+
+// ApplyToBuilder applies this configuration to the given builder options.
+func (w WithData) ApplyToBuilder(in *Options) {
+	in.Mutators = append(in.Mutators, func(object client.Object) error {
+		switch o := object.(type) {
+		case *corev1.ConfigMap:
+			o.Data = copyMap(w)
+		case *corev1.Secret:
+			o.Data = make(map[string][]byte, len(w))
+			for key, value := range w {
+				o.Data[key] = []byte(value)
+			}
+		default:
+			return newUnsupportedOptionError(w, object)
+		}
+		return nil
+	})
+}
+
+// WithBinaryData allows to define the binary data of a ConfigMap or the data of a Secret.
+type WithBinaryData map[string][]byte
+
+// ApplyToBuilder applies this configuration to the given builder options.
+func (w WithBinaryData) ApplyToBuilder(in *Options) {
+	in.Mutators = append(in.Mutators, func(object client.Object) error {
+		data := make(map[string][]byte, len(w))
+		for key, value := range w {
+			data[key] = append([]byte{}, value...)
+		}
+
+		switch o := object.(type) {
+		case *corev1.ConfigMap:
+			o.BinaryData = data
+		case *corev1.Secret:
+			o.Data = data
+		default:
+			return newUnsupportedOptionError(w, object)
+		}
+		return nil
+	})
+}
+
+// WithSecretType allows to define the type of a Secret.
+type WithSecretType corev1.SecretType
+
+// ApplyToBuilder applies this configuration to the given builder options.
+func (w WithSecretType) ApplyToBuilder(in *Options) {
+	in.Mutators = append(in.Mutators, func(object client.Object) error {
+		secret, ok := object.(*corev1.Secret)
+		if !ok {
+			return newUnsupportedOptionError(w, object)
+		}
+
+		secret.Type = corev1.SecretType(w)
+		return nil
+	})
+}
+
+// WithAutomountServiceAccountToken allows to define whenever the token of a ServiceAccount is automatically mounted.
+type WithAutomountServiceAccountToken bool
+
+// ApplyToBuilder applies this configuration to the given builder options.
+func (w WithAutomountServiceAccountToken) ApplyToBuilder(in *Options) {
+	in.Mutators = append(in.Mutators, func(object client.Object) error {
+		serviceAccount, ok := object.(*corev1.ServiceAccount)
+		if !ok {
+			return newUnsupportedOptionError(w, object)
+		}
+
+		serviceAccount.AutomountServiceAccountToken = ptr.To(bool(w))
+		return nil
+	})
+}
+
+// copyMap returns a copy of the provided map.
+func copyMap(in map[string]string) map[string]string {
+	out := make(map[string]string, len(in))
+	for key, value := range in {
+		out[key] = value
+	}
+	return out
+}
@@ -0,0 +1,131 @@
+// Licensed to Alexandre VILAIN under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Alexandre VILAIN licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package builders
+
+import (
+	"fmt"
+
+	"github.com/alexandrevilain/controller-tools/pkg/resource"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Option is some configuration that modifies options for a builder.
+type Option interface {
+	// ApplyToBuilder applies this configuration to the given builder options.
+	ApplyToBuilder(*Options)
+}
+
+// MutateFunc sets fields of the provided object.
+type MutateFunc func(client.Object) error
+
+// Options contains options for a builder.
+type Options struct {
+	// Enabled is whenever the builder is enabled. Defaults to true.
+	Enabled bool
+	// Labels are merged into the object's labels.
+	Labels map[string]string
+	// Annotations are merged into the object's annotations.
+	Annotations map[string]string
+	// Dependencies are the resources the builder's resource depends on.
+	Dependencies []resource.Dependency
+	// IgnoredFields are fields managed outside of the builder (see resource.IgnoreFields for the path syntax).
+	IgnoredFields []string
+	// Mutators are called in order to set the object's fields.
+	Mutators []MutateFunc
+}
+
+// WithEnabled allows to define whenever the builder is enabled.
+type WithEnabled bool
+
+// ApplyToBuilder applies this configuration to the given builder options.
+func (w WithEnabled) ApplyToBuilder(in *Options) {
+	in.Enabled = bool(w)
+}
+
+// WithLabels allows to define labels merged into the object's labels.
+type WithLabels map[string]string
+
+// ApplyToBuilder applies this configuration to the given builder options.
+func (w WithLabels) ApplyToBuilder(in *Options) {
+	in.Labels = mergeMaps(in.Labels, w)
+}
+
+// WithAnnotations allows to define annotations merged into the object's annotations.
+type WithAnnotations map[string]string
+
+// ApplyToBuilder applies this configuration to the given builder options.
+func (w WithAnnotations) ApplyToBuilder(in *Options) {
+	in.Annotations = mergeMaps(in.Annotations, w)
+}
+
+// WithDependencies allows to define the resources the builder's resource depends on.
+type WithDependencies []resource.Dependency
+
+// ApplyToBuilder applies this configuration to the given builder options.
+func (w WithDependencies) ApplyToBuilder(in *Options) {
+	in.Dependencies = append(in.Dependencies, w...)
+}
+
+// WithIgnoredFields allows to define fields managed outside of the builder,
+// like replicas managed by an HorizontalPodAutoscaler.
+type WithIgnoredFields []string
+
+// ApplyToBuilder applies this configuration to the given builder options.
+func (w WithIgnoredFields) ApplyToBuilder(in *Options) {
+	in.IgnoredFields = append(in.IgnoredFields, w...)
+}
+
+// mutateOption is an option adding a mutator.
+type mutateOption MutateFunc
+
+// ApplyToBuilder applies this configuration to the given builder options.
+func (m mutateOption) ApplyToBuilder(in *Options) {
+	in.Mutators = append(in.Mutators, MutateFunc(m))
+}
+
+// Mutate returns an option calling the provided function to set fields of the object.
+// It's called after the mutations of options provided before it.
+// The builder returns a resource.TypeMismatchError when its objects aren't of type T.
+func Mutate[T client.Object](mutate func(T) error) Option {
+	return mutateOption(func(object client.Object) error {
+		typed, err := resource.Cast[T](object)
+		if err != nil {
+			return err
+		}
+		return mutate(typed)
+	})
+}
+
+// UnsupportedOptionError is returned when an option is used with a builder of a kind it doesn't support.
+type UnsupportedOptionError struct {
+	// Option is the name of the option.
+	Option string
+	// Object is the type of the builder's object.
+	Object string
+}
+
+func (e *UnsupportedOptionError) Error() string {
+	return fmt.Sprintf("option %s doesn't support %s objects", e.Option, e.Object)
+}
+
+func newUnsupportedOptionError(option Option, object client.Object) error {
+	return &UnsupportedOptionError{
+		Option: fmt.Sprintf("%T", option),
+		Object: fmt.Sprintf("%T", object),
+	}
+}
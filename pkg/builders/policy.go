@@ -0,0 +1,65 @@
+// Licensed to Alexandre VILAIN under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Alexandre VILAIN licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package builders
+
+import (
+	"github.com/alexandrevilain/controller-tools/pkg/resource"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// NewPodDisruptionBudget returns a builder of PodDisruptionBudgets.
+// Supported options are WithSelector, WithMinAvailable and WithMaxUnavailable.
+func NewPodDisruptionBudget(name, namespace string, opts ...Option) resource.Builder {
+	return resource.NewBuilder(New(name, namespace, func() *policyv1.PodDisruptionBudget { return &policyv1.PodDisruptionBudget{} }, opts...))
+}
+
+// WithMinAvailable allows to define the minimum number or percentage of available pods of a PodDisruptionBudget.
+type WithMinAvailable intstr.IntOrString
+
+// ApplyToBuilder applies this configuration to the given builder options.
+func (w WithMinAvailable) ApplyToBuilder(in *Options) {
+	in.Mutators = append(in.Mutators, func(object client.Object) error {
+		pdb, ok := object.(*policyv1.PodDisruptionBudget)
+		if !ok {
+			return newUnsupportedOptionError(w, object)
+		}
+
+		value := intstr.IntOrString(w)
+		pdb.Spec.MinAvailable = &value
+		return nil
+	})
+}
+
+// WithMaxUnavailable allows to define the maximum number or percentage of unavailable pods of a PodDisruptionBudget.
+type WithMaxUnavailable intstr.IntOrString
+
+// ApplyToBuilder applies this configuration to the given builder options.
+func (w WithMaxUnavailable) ApplyToBuilder(in *Options) {
+	in.Mutators = append(in.Mutators, func(object client.Object) error {
+		pdb, ok := object.(*policyv1.PodDisruptionBudget)
+		if !ok {
+			return newUnsupportedOptionError(w, object)
+		}
+
+		value := intstr.IntOrString(w)
+		pdb.Spec.MaxUnavailable = &value
+		return nil
+	})
+}
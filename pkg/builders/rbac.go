@@ -0,0 +1,110 @@
+// Licensed to Alexandre VILAIN under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Alexandre VILAIN licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package builders
+
+import (
+	"github.com/alexandrevilain/controller-tools/pkg/resource"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// NewRole returns a builder of Roles.
+// Supported option is WithRules.
+func NewRole(name, namespace string, opts ...Option) resource.Builder {
+	return resource.NewBuilder(New(name, namespace, func() *rbacv1.Role { return &rbacv1.Role{} }, opts...))
+}
+
+// NewClusterRole returns a builder of ClusterRoles.
+// Supported option is WithRules.
+func NewClusterRole(name string, opts ...Option) resource.Builder {
+	return resource.NewBuilder(New(name, "", func() *rbacv1.ClusterRole { return &rbacv1.ClusterRole{} }, opts...))
+}
+
+// NewRoleBinding returns a builder of RoleBindings.
+// Supported options are WithRoleRef and WithSubjects.
+func NewRoleBinding(name, namespace string, opts ...Option) resource.Builder {
+	return resource.NewBuilder(New(name, namespace, func() *rbacv1.RoleBinding { return &rbacv1.RoleBinding{} }, opts...))
+}
+
+// NewClusterRoleBinding returns a builder of ClusterRoleBindings.
+// Supported options are WithRoleRef and WithSubjects.
+func NewClusterRoleBinding(name string, opts ...Option) resource.Builder {
+	return resource.NewBuilder(New(name, "", func() *rbacv1.ClusterRoleBinding { return &rbacv1.ClusterRoleBinding{} }, opts...))
+}
+
+// WithRules allows to define the rules of a Role or a ClusterRole.
+type WithRules []rbacv1.PolicyRule
+
+// ApplyToBuilder applies this configuration to the given builder options.
+func (w WithRules) ApplyToBuilder(in *Options) {
+	in.Mutators = append(in.Mutators, func(object client.Object) error {
+		rules := make([]rbacv1.PolicyRule, 0, len(w))
+		for _, rule := range w {
+			rules = append(rules, *rule.DeepCopy())
+		}
+
+		switch o := object.(type) {
+		case *rbacv1.Role:
+			o.Rules = rules
+		case *rbacv1.ClusterRole:
+			o.Rules = rules
+		default:
+			return newUnsupportedOptionError(w, object)
+		}
+		return nil
+	})
+}
+
+// WithRoleRef allows to define the role referenced by a RoleBinding or a ClusterRoleBinding.
+// The role reference of a binding can't be changed once created.
+type WithRoleRef rbacv1.RoleRef
+
+// ApplyToBuilder applies this configuration to the given builder options.
+func (w WithRoleRef) ApplyToBuilder(in *Options) {
+	in.Mutators = append(in.Mutators, func(object client.Object) error {
+		switch o := object.(type) {
+		case *rbacv1.RoleBinding:
+			o.RoleRef = rbacv1.RoleRef(w)
+		case *rbacv1.ClusterRoleBinding:
+			o.RoleRef = rbacv1.RoleRef(w)
+		default:
+			return newUnsupportedOptionError(w, object)
+		}
+		return nil
+	})
+}
+
+// WithSubjects allows to define the subjects of a RoleBinding or a ClusterRoleBinding.
+type WithSubjects []rbacv1.Subject
+
+// ApplyToBuilder applies this configuration to the given builder options.
+func (w WithSubjects) ApplyToBuilder(in *Options) {
+	in.Mutators = append(in.Mutators, func(object client.Object) error {
+		subjects := append([]rbacv1.Subject{}, w...)
+
+		switch o := object.(type) {
+		case *rbacv1.RoleBinding:
+			o.Subjects = subjects
+		case *rbacv1.ClusterRoleBinding:
+			o.Subjects = subjects
+		default:
+			return newUnsupportedOptionError(w, object)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,83 @@
+// Licensed to Alexandre VILAIN under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Alexandre VILAIN licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package conditions provides helpers to manage metav1.Condition on objects exposing a conditions slice.
+package conditions
+
+import (
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ReadyCondition is the condition type summarizing the readiness of an object.
+const ReadyCondition = "Ready"
+
+// Getter is implemented by objects exposing their conditions.
+type Getter interface {
+	client.Object
+	// GetConditions returns the object's conditions.
+	GetConditions() []metav1.Condition
+}
+
+// Get returns the condition with the given type, or nil if the condition doesn't exist.
+func Get(from Getter, conditionType string) *metav1.Condition {
+	return apimeta.FindStatusCondition(from.GetConditions(), conditionType)
+}
+
+// Has returns true if a condition with the given type exists.
+func Has(from Getter, conditionType string) bool {
+	return Get(from, conditionType) != nil
+}
+
+// IsTrue returns true if the condition with the given type is True.
+func IsTrue(from Getter, conditionType string) bool {
+	return apimeta.IsStatusConditionTrue(from.GetConditions(), conditionType)
+}
+
+// IsFalse returns true if the condition with the given type is False.
+func IsFalse(from Getter, conditionType string) bool {
+	return apimeta.IsStatusConditionFalse(from.GetConditions(), conditionType)
+}
+
+// IsUnknown returns true if the condition with the given type is Unknown or doesn't exist.
+func IsUnknown(from Getter, conditionType string) bool {
+	condition := Get(from, conditionType)
+	return condition == nil || condition.Status == metav1.ConditionUnknown
+}
+
+// GetReason returns the reason of the condition with the given type, or an empty string if the condition doesn't exist.
+func GetReason(from Getter, conditionType string) string {
+	if condition := Get(from, conditionType); condition != nil {
+		return condition.Reason
+	}
+	return ""
+}
+
+// GetMessage returns the message of the condition with the given type, or an empty string if the condition doesn't exist.
+func GetMessage(from Getter, conditionType string) string {
+	if condition := Get(from, conditionType); condition != nil {
+		return condition.Message
+	}
+	return ""
+}
+
+// IsUpToDate returns true if the condition with the given type was set for the object's current generation.
+func IsUpToDate(from Getter, conditionType string) bool {
+	condition := Get(from, conditionType)
+	return condition != nil && condition.ObservedGeneration == from.GetGeneration()
+}
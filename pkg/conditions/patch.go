@@ -0,0 +1,146 @@
+// Licensed to Alexandre VILAIN under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Alexandre VILAIN licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package conditions
+
+import (
+	"fmt"
+	"slices"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PatchOperationType defines the type of a condition patch operation.
+type PatchOperationType string
+
+const (
+	// AddConditionPatch means that a condition was added.
+	AddConditionPatch PatchOperationType = "Add"
+	// ChangeConditionPatch means that a condition was changed.
+	ChangeConditionPatch PatchOperationType = "Change"
+	// RemoveConditionPatch means that a condition was removed.
+	RemoveConditionPatch PatchOperationType = "Remove"
+)
+
+// PatchOperation defines an operation changing a single condition.
+type PatchOperation struct {
+	Before *metav1.Condition
+	After  *metav1.Condition
+	Op     PatchOperationType
+}
+
+// Patch defines the list of operations changing a list of conditions into another.
+type Patch []PatchOperation
+
+// ConflictError is returned when applying a patch on a condition that was concurrently changed by another writer.
+type ConflictError struct {
+	// Type is the type of the conflicting condition.
+	Type string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("condition %s was modified concurrently", e.Type)
+}
+
+// NewPatch returns the patch changing the conditions of before into the conditions of after.
+func NewPatch(before, after Getter) Patch {
+	patch := Patch{}
+
+	for i := range after.GetConditions() {
+		afterCondition := &after.GetConditions()[i]
+		beforeCondition := Get(before, afterCondition.Type)
+		if beforeCondition == nil {
+			patch = append(patch, PatchOperation{Op: AddConditionPatch, After: afterCondition})
+			continue
+		}
+
+		if !hasSameState(beforeCondition, afterCondition) {
+			patch = append(patch, PatchOperation{Op: ChangeConditionPatch, Before: beforeCondition, After: afterCondition})
+		}
+	}
+
+	for i := range before.GetConditions() {
+		beforeCondition := &before.GetConditions()[i]
+		if !Has(after, beforeCondition.Type) {
+			patch = append(patch, PatchOperation{Op: RemoveConditionPatch, Before: beforeCondition})
+		}
+	}
+
+	return patch
+}
+
+// IsZero returns true if the patch doesn't contain any operation.
+func (p Patch) IsZero() bool {
+	return len(p) == 0
+}
+
+// Apply applies the patch on the latest version of the object.
+// When a condition changed by the patch was also changed on latest by another writer,
+// a ConflictError is returned unless the condition type is part of the owned conditions,
+// in which case the patch's version of the condition wins.
+func (p Patch) Apply(latest Setter, ownedConditions ...string) error {
+	for _, op := range p {
+		switch op.Op {
+		case AddConditionPatch:
+			latestCondition := Get(latest, op.After.Type)
+			if latestCondition != nil && !hasSameState(latestCondition, op.After) && !slices.Contains(ownedConditions, op.After.Type) {
+				return &ConflictError{Type: op.After.Type}
+			}
+			setCondition(latest, *op.After)
+		case ChangeConditionPatch:
+			latestCondition := Get(latest, op.After.Type)
+			if latestCondition != nil && !hasSameState(latestCondition, op.Before) && !hasSameState(latestCondition, op.After) &&
+				!slices.Contains(ownedConditions, op.After.Type) {
+				return &ConflictError{Type: op.After.Type}
+			}
+			setCondition(latest, *op.After)
+		case RemoveConditionPatch:
+			latestCondition := Get(latest, op.Before.Type)
+			if latestCondition == nil {
+				continue
+			}
+			if !hasSameState(latestCondition, op.Before) && !slices.Contains(ownedConditions, op.Before.Type) {
+				return &ConflictError{Type: op.Before.Type}
+			}
+			Delete(latest, op.Before.Type)
+		}
+	}
+
+	return nil
+}
+
+// setCondition sets the condition as is, without altering its observed generation nor its transition time.
+func setCondition(to Setter, condition metav1.Condition) {
+	conditions := to.GetConditions()
+	for i := range conditions {
+		if conditions[i].Type == condition.Type {
+			conditions[i] = condition
+			to.SetConditions(conditions)
+			return
+		}
+	}
+	to.SetConditions(append(conditions, condition))
+}
+
+// hasSameState returns true if both conditions have the same state, ignoring their transition time.
+func hasSameState(a, b *metav1.Condition) bool {
+	return a.Type == b.Type &&
+		a.Status == b.Status &&
+		a.Reason == b.Reason &&
+		a.Message == b.Message &&
+		a.ObservedGeneration == b.ObservedGeneration
+}
@@ -0,0 +1,122 @@
+// Licensed to Alexandre VILAIN under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Alexandre VILAIN licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package conditions
+
+import (
+	"fmt"
+
+	"github.com/alexandrevilain/controller-tools/pkg/resource"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Setter is implemented by objects allowing to set their conditions.
+type Setter interface {
+	Getter
+	// SetConditions replaces the object's conditions.
+	SetConditions([]metav1.Condition)
+}
+
+// Set sets the provided condition on the object, replacing any existing condition with the same type.
+// The condition's observed generation is set to the object's generation.
+// LastTransitionTime is only updated when the condition status changes.
+func Set(to Setter, condition metav1.Condition) {
+	conditions := to.GetConditions()
+	condition.ObservedGeneration = to.GetGeneration()
+	apimeta.SetStatusCondition(&conditions, condition)
+	to.SetConditions(conditions)
+}
+
+// MarkTrue sets a condition with the given type to True.
+func MarkTrue(to Setter, conditionType, reason, messageFormat string, messageArgs ...any) {
+	Set(to, newCondition(conditionType, metav1.ConditionTrue, reason, messageFormat, messageArgs...))
+}
+
+// MarkFalse sets a condition with the given type to False.
+func MarkFalse(to Setter, conditionType, reason, messageFormat string, messageArgs ...any) {
+	Set(to, newCondition(conditionType, metav1.ConditionFalse, reason, messageFormat, messageArgs...))
+}
+
+// MarkUnknown sets a condition with the given type to Unknown.
+func MarkUnknown(to Setter, conditionType, reason, messageFormat string, messageArgs ...any) {
+	Set(to, newCondition(conditionType, metav1.ConditionUnknown, reason, messageFormat, messageArgs...))
+}
+
+// Delete removes the condition with the given type.
+func Delete(to Setter, conditionType string) {
+	conditions := to.GetConditions()
+	apimeta.RemoveStatusCondition(&conditions, conditionType)
+	to.SetConditions(conditions)
+}
+
+// Mirror sets the targetType condition on the object using the sourceType condition of the provided source object.
+// If the source condition doesn't exist, the target condition is set to Unknown.
+func Mirror(to Setter, targetType string, from Getter, sourceType string) {
+	source := Get(from, sourceType)
+	if source == nil {
+		MarkUnknown(to, targetType, "ConditionNotFound", "%s condition not found on %s", sourceType, from.GetName())
+		return
+	}
+
+	Set(to, metav1.Condition{
+		Type:    targetType,
+		Status:  source.Status,
+		Reason:  source.Reason,
+		Message: source.Message,
+	})
+}
+
+// SetFromStatus sets a condition with the given type on the object reflecting the provided resource status.
+// The condition is True when the resource is ready, otherwise it's False and its reason is the resource kstatus state.
+func SetFromStatus(to Setter, conditionType string, status *resource.Status) {
+	SetFromStatuses(to, conditionType, status)
+}
+
+// SetFromStatuses sets a condition with the given type on the object summarizing the provided resources statuses.
+// The condition is True when all resources are ready, otherwise it's False and reflects the first resource not ready.
+func SetFromStatuses(to Setter, conditionType string, statuses ...*resource.Status) {
+	for _, status := range statuses {
+		if status.Ready {
+			continue
+		}
+
+		reason := string(status.State)
+		if reason == "" {
+			reason = "NotReady"
+		}
+
+		message := status.Message
+		if message == "" {
+			message = "resource is not ready"
+		}
+
+		MarkFalse(to, conditionType, reason, "%s %s: %s", status.GVK.Kind, status.Name, message)
+		return
+	}
+
+	MarkTrue(to, conditionType, "Ready", "All resources are ready")
+}
+
+func newCondition(conditionType string, status metav1.ConditionStatus, reason, messageFormat string, messageArgs ...any) metav1.Condition {
+	return metav1.Condition{
+		Type:    conditionType,
+		Status:  status,
+		Reason:  reason,
+		Message: fmt.Sprintf(messageFormat, messageArgs...),
+	}
+}
@@ -0,0 +1,132 @@
+// Licensed to Alexandre VILAIN under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Alexandre VILAIN licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package conditions_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alexandrevilain/controller-tools/pkg/conditions"
+	"github.com/alexandrevilain/controller-tools/pkg/fake"
+	"github.com/alexandrevilain/controller-tools/pkg/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kstatus "sigs.k8s.io/cli-utils/pkg/kstatus/status"
+)
+
+func TestSet(t *testing.T) {
+	obj := fake.NewObject("test", "default")
+	obj.Generation = 2
+
+	conditions.MarkFalse(obj, "Available", "Pending", "waiting for %d replicas", 3)
+
+	condition := conditions.Get(obj, "Available")
+	require.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, "Pending", condition.Reason)
+	assert.Equal(t, "waiting for 3 replicas", condition.Message)
+	assert.Equal(t, int64(2), condition.ObservedGeneration)
+	assert.True(t, conditions.IsFalse(obj, "Available"))
+	assert.True(t, conditions.IsUpToDate(obj, "Available"))
+
+	past := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+	obj.Status.Conditions[0].LastTransitionTime = past
+
+	// Updating reason and message without changing status keeps the transition time.
+	conditions.MarkFalse(obj, "Available", "Scaling", "waiting for %d replicas", 1)
+	assert.Equal(t, past, conditions.Get(obj, "Available").LastTransitionTime)
+	assert.Equal(t, "Scaling", conditions.GetReason(obj, "Available"))
+	assert.Equal(t, "waiting for 1 replicas", conditions.GetMessage(obj, "Available"))
+
+	// Changing the status updates the transition time.
+	conditions.MarkTrue(obj, "Available", "Available", "")
+	assert.True(t, conditions.IsTrue(obj, "Available"))
+	assert.NotEqual(t, past, conditions.Get(obj, "Available").LastTransitionTime)
+
+	conditions.Delete(obj, "Available")
+	assert.False(t, conditions.Has(obj, "Available"))
+	assert.True(t, conditions.IsUnknown(obj, "Available"))
+}
+
+func TestMirror(t *testing.T) {
+	source := fake.NewObject("source", "default")
+	target := fake.NewObject("target", "default")
+
+	conditions.Mirror(target, "SourceReady", source, conditions.ReadyCondition)
+	assert.True(t, conditions.IsUnknown(target, "SourceReady"))
+	assert.Equal(t, "ConditionNotFound", conditions.GetReason(target, "SourceReady"))
+
+	conditions.MarkFalse(source, conditions.ReadyCondition, "Failed", "something went wrong")
+	conditions.Mirror(target, "SourceReady", source, conditions.ReadyCondition)
+	assert.True(t, conditions.IsFalse(target, "SourceReady"))
+	assert.Equal(t, "Failed", conditions.GetReason(target, "SourceReady"))
+	assert.Equal(t, "something went wrong", conditions.GetMessage(target, "SourceReady"))
+}
+
+func TestSetFromStatuses(t *testing.T) {
+	gvk := appsv1.SchemeGroupVersion.WithKind("Deployment")
+
+	tests := map[string]struct {
+		statuses        []*resource.Status
+		expectedStatus  metav1.ConditionStatus
+		expectedReason  string
+		expectedMessage string
+	}{
+		"all ready": {
+			statuses: []*resource.Status{
+				{GVK: gvk, Name: "a", Ready: true, State: kstatus.CurrentStatus},
+				{GVK: gvk, Name: "b", Ready: true, State: kstatus.CurrentStatus},
+			},
+			expectedStatus:  metav1.ConditionTrue,
+			expectedReason:  "Ready",
+			expectedMessage: "All resources are ready",
+		},
+		"one in progress": {
+			statuses: []*resource.Status{
+				{GVK: gvk, Name: "a", Ready: true, State: kstatus.CurrentStatus},
+				{GVK: gvk, Name: "b", State: kstatus.InProgressStatus, Message: "Replicas: 0/1"},
+			},
+			expectedStatus:  metav1.ConditionFalse,
+			expectedReason:  "InProgress",
+			expectedMessage: "Deployment b: Replicas: 0/1",
+		},
+		"one failed": {
+			statuses: []*resource.Status{
+				{GVK: gvk, Name: "a", State: kstatus.FailedStatus, Message: "Progress deadline exceeded"},
+			},
+			expectedStatus:  metav1.ConditionFalse,
+			expectedReason:  "Failed",
+			expectedMessage: "Deployment a: Progress deadline exceeded",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(tt *testing.T) {
+			obj := fake.NewObject("test", "default")
+			conditions.SetFromStatuses(obj, "ResourcesReady", test.statuses...)
+
+			condition := conditions.Get(obj, "ResourcesReady")
+			require.NotNil(tt, condition)
+			assert.Equal(tt, test.expectedStatus, condition.Status)
+			assert.Equal(tt, test.expectedReason, condition.Reason)
+			assert.Equal(tt, test.expectedMessage, condition.Message)
+		})
+	}
+}
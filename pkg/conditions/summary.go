@@ -0,0 +1,82 @@
+// Licensed to Alexandre VILAIN under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Alexandre VILAIN licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package conditions
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Summary returns a Ready condition summarizing the provided condition types of the object.
+// When no condition types are provided, all conditions of the object except Ready are summarized.
+// The summary is True when all summarized conditions are True. Otherwise it's False, using the reason
+// and message of the first False condition, or Unknown using the first Unknown or missing condition.
+func Summary(from Getter, conditionTypes ...string) metav1.Condition {
+	if len(conditionTypes) == 0 {
+		for _, condition := range from.GetConditions() {
+			if condition.Type != ReadyCondition {
+				conditionTypes = append(conditionTypes, condition.Type)
+			}
+		}
+	}
+
+	var unknown *metav1.Condition
+	for _, conditionType := range conditionTypes {
+		condition := Get(from, conditionType)
+		switch {
+		case condition == nil:
+			if unknown == nil {
+				unknown = &metav1.Condition{
+					Reason:  "ConditionNotFound",
+					Message: conditionType + " condition not found",
+				}
+			}
+		case condition.Status == metav1.ConditionFalse:
+			return metav1.Condition{
+				Type:    ReadyCondition,
+				Status:  metav1.ConditionFalse,
+				Reason:  condition.Reason,
+				Message: condition.Message,
+			}
+		case condition.Status == metav1.ConditionUnknown:
+			if unknown == nil {
+				unknown = condition
+			}
+		}
+	}
+
+	if unknown != nil {
+		return metav1.Condition{
+			Type:    ReadyCondition,
+			Status:  metav1.ConditionUnknown,
+			Reason:  unknown.Reason,
+			Message: unknown.Message,
+		}
+	}
+
+	return metav1.Condition{
+		Type:   ReadyCondition,
+		Status: metav1.ConditionTrue,
+		Reason: "Ready",
+	}
+}
+
+// SetSummary sets the Ready condition on the object, summarizing the provided condition types.
+// See Summary for details.
+func SetSummary(to Setter, conditionTypes ...string) {
+	Set(to, Summary(to, conditionTypes...))
+}
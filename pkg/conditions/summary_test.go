@@ -0,0 +1,104 @@
+// Licensed to Alexandre VILAIN under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Alexandre VILAIN licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package conditions_test
+
+import (
+	"testing"
+
+	"github.com/alexandrevilain/controller-tools/pkg/conditions"
+	"github.com/alexandrevilain/controller-tools/pkg/fake"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSummary(t *testing.T) {
+	tests := map[string]struct {
+		conditions      []metav1.Condition
+		conditionTypes  []string
+		expectedStatus  metav1.ConditionStatus
+		expectedReason  string
+		expectedMessage string
+	}{
+		"all true": {
+			conditions: []metav1.Condition{
+				{Type: "A", Status: metav1.ConditionTrue, Reason: "OK"},
+				{Type: "B", Status: metav1.ConditionTrue, Reason: "OK"},
+			},
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: "Ready",
+		},
+		"ready condition is not summarized": {
+			conditions: []metav1.Condition{
+				{Type: "A", Status: metav1.ConditionTrue, Reason: "OK"},
+				{Type: conditions.ReadyCondition, Status: metav1.ConditionFalse, Reason: "Old"},
+			},
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: "Ready",
+		},
+		"false takes precedence over unknown": {
+			conditions: []metav1.Condition{
+				{Type: "A", Status: metav1.ConditionUnknown, Reason: "Checking"},
+				{Type: "B", Status: metav1.ConditionFalse, Reason: "Broken", Message: "B is broken"},
+			},
+			expectedStatus:  metav1.ConditionFalse,
+			expectedReason:  "Broken",
+			expectedMessage: "B is broken",
+		},
+		"unknown": {
+			conditions: []metav1.Condition{
+				{Type: "A", Status: metav1.ConditionTrue, Reason: "OK"},
+				{Type: "B", Status: metav1.ConditionUnknown, Reason: "Checking", Message: "checking B"},
+			},
+			expectedStatus:  metav1.ConditionUnknown,
+			expectedReason:  "Checking",
+			expectedMessage: "checking B",
+		},
+		"missing condition": {
+			conditions: []metav1.Condition{
+				{Type: "A", Status: metav1.ConditionTrue, Reason: "OK"},
+			},
+			conditionTypes:  []string{"A", "B"},
+			expectedStatus:  metav1.ConditionUnknown,
+			expectedReason:  "ConditionNotFound",
+			expectedMessage: "B condition not found",
+		},
+		"only provided condition types": {
+			conditions: []metav1.Condition{
+				{Type: "A", Status: metav1.ConditionTrue, Reason: "OK"},
+				{Type: "B", Status: metav1.ConditionFalse, Reason: "Broken"},
+			},
+			conditionTypes: []string{"A"},
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: "Ready",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(tt *testing.T) {
+			obj := fake.NewObject("test", "default")
+			obj.Status.Conditions = test.conditions
+
+			conditions.SetSummary(obj, test.conditionTypes...)
+
+			ready := conditions.Get(obj, conditions.ReadyCondition)
+			assert.Equal(tt, test.expectedStatus, ready.Status)
+			assert.Equal(tt, test.expectedReason, ready.Reason)
+			assert.Equal(tt, test.expectedMessage, ready.Message)
+		})
+	}
+}
@@ -18,8 +18,11 @@
 package fake
 
 import (
+	"github.com/alexandrevilain/controller-tools/pkg/resource"
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -29,6 +32,7 @@ type DeploymentBuilder struct {
 	Namespace    string
 	IsEnabled    bool
 	MutateObject func(client.Object)
+	DependsOn    []resource.Dependency
 }
 
 func (b *DeploymentBuilder) Build() client.Object {
@@ -44,8 +48,16 @@ func (b *DeploymentBuilder) Enabled() bool {
 	return b.IsEnabled
 }
 
+func (b *DeploymentBuilder) Dependencies() []resource.Dependency {
+	return b.DependsOn
+}
+
 func (b *DeploymentBuilder) Update(object client.Object) error {
-	deploy := object.(*appsv1.Deployment)
+	deploy, err := resource.Cast[*appsv1.Deployment](object)
+	if err != nil {
+		return err
+	}
+
 	deploy.Spec = appsv1.DeploymentSpec{
 		Selector: &metav1.LabelSelector{
 			MatchLabels: map[string]string{"foo": "bar"},
@@ -79,3 +91,141 @@ func NewDeploymentBuilder(name, namespace string) *DeploymentBuilder {
 		IsEnabled: true,
 	}
 }
+
+// ComparingDeploymentBuilder is a DeploymentBuilder using a custom comparison function.
+type ComparingDeploymentBuilder struct {
+	*DeploymentBuilder
+	EqualFunc func(current, desired client.Object) bool
+}
+
+func (b *ComparingDeploymentBuilder) Equal(current, desired client.Object) bool {
+	return b.EqualFunc(current, desired)
+}
+
+type ClusterRoleBuilder struct {
+	Name      string
+	IsEnabled bool
+}
+
+func (b *ClusterRoleBuilder) Build() client.Object {
+	return &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: b.Name,
+		},
+	}
+}
+
+func (b *ClusterRoleBuilder) Enabled() bool {
+	return b.IsEnabled
+}
+
+func (b *ClusterRoleBuilder) Update(object client.Object) error {
+	role, err := resource.Cast[*rbacv1.ClusterRole](object)
+	if err != nil {
+		return err
+	}
+
+	role.Rules = []rbacv1.PolicyRule{
+		{
+			APIGroups: []string{""},
+			Resources: []string{"configmaps"},
+			Verbs:     []string{"get", "list", "watch"},
+		},
+	}
+	return nil
+}
+
+func NewClusterRoleBuilder(name string) *ClusterRoleBuilder {
+	return &ClusterRoleBuilder{
+		Name:      name,
+		IsEnabled: true,
+	}
+}
+
+// ConfigMapBuilder is a resource.TypedBuilder of ConfigMaps.
+type ConfigMapBuilder struct {
+	Name      string
+	Namespace string
+	IsEnabled bool
+	Data      map[string]string
+	DependsOn []resource.Dependency
+}
+
+func (b *ConfigMapBuilder) Build() *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      b.Name,
+			Namespace: b.Namespace,
+		},
+	}
+}
+
+func (b *ConfigMapBuilder) Enabled() bool {
+	return b.IsEnabled
+}
+
+func (b *ConfigMapBuilder) Dependencies() []resource.Dependency {
+	return b.DependsOn
+}
+
+func (b *ConfigMapBuilder) Update(configMap *corev1.ConfigMap) error {
+	configMap.Data = b.Data
+	return nil
+}
+
+func NewConfigMapBuilder(name, namespace string) *ConfigMapBuilder {
+	return &ConfigMapBuilder{
+		Name:      name,
+		Namespace: namespace,
+		IsEnabled: true,
+	}
+}
+
+// JobBuilder is a builder of Jobs running the provided command.
+type JobBuilder struct {
+	Name      string
+	Namespace string
+	Image     string
+	Command   []string
+}
+
+func (b *JobBuilder) Build() client.Object {
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      b.Name,
+			Namespace: b.Namespace,
+		},
+	}
+}
+
+func (b *JobBuilder) Enabled() bool {
+	return true
+}
+
+func (b *JobBuilder) Update(object client.Object) error {
+	job, err := resource.Cast[*batchv1.Job](object)
+	if err != nil {
+		return err
+	}
+
+	job.Spec.Template.Spec = corev1.PodSpec{
+		RestartPolicy: corev1.RestartPolicyNever,
+		Containers: []corev1.Container{
+			{
+				Name:    "job",
+				Image:   b.Image,
+				Command: b.Command,
+			},
+		},
+	}
+	return nil
+}
+
+func NewJobBuilder(name, namespace string, command []string) *JobBuilder {
+	return &JobBuilder{
+		Name:      name,
+		Namespace: namespace,
+		Image:     "busybox",
+		Command:   command,
+	}
+}
@@ -0,0 +1,127 @@
+// Licensed to Alexandre VILAIN under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Alexandre VILAIN licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package fake
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// GroupVersion is the group version of fake objects.
+var GroupVersion = schema.GroupVersion{Group: "fake.controller-tools.alexandrevilain.dev", Version: "v1"}
+
+// AddToScheme adds fake objects to the provided scheme.
+func AddToScheme(scheme *runtime.Scheme) error {
+	scheme.AddKnownTypes(GroupVersion, &Object{}, &ObjectList{})
+	metav1.AddToGroupVersion(scheme, GroupVersion)
+	return nil
+}
+
+// Object is a fake custom resource exposing conditions in its status.
+type Object struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ObjectSpec   `json:"spec,omitempty"`
+	Status ObjectStatus `json:"status,omitempty"`
+}
+
+type ObjectSpec struct {
+	Value string `json:"value,omitempty"`
+}
+
+type ObjectStatus struct {
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// ObjectList is a list of fake objects.
+type ObjectList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []Object `json:"items"`
+}
+
+func NewObject(name, namespace string) *Object {
+	return &Object{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+}
+
+func (o *Object) GetConditions() []metav1.Condition {
+	return o.Status.Conditions
+}
+
+func (o *Object) SetConditions(conditions []metav1.Condition) {
+	o.Status.Conditions = conditions
+}
+
+func (o *Object) DeepCopyInto(out *Object) {
+	*out = *o
+	out.TypeMeta = o.TypeMeta
+	o.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = o.Spec
+	if o.Status.Conditions != nil {
+		out.Status.Conditions = make([]metav1.Condition, len(o.Status.Conditions))
+		for i := range o.Status.Conditions {
+			o.Status.Conditions[i].DeepCopyInto(&out.Status.Conditions[i])
+		}
+	}
+}
+
+func (o *Object) DeepCopy() *Object {
+	if o == nil {
+		return nil
+	}
+	out := new(Object)
+	o.DeepCopyInto(out)
+	return out
+}
+
+func (o *Object) DeepCopyObject() runtime.Object {
+	return o.DeepCopy()
+}
+
+func (l *ObjectList) DeepCopyInto(out *ObjectList) {
+	*out = *l
+	out.TypeMeta = l.TypeMeta
+	l.ListMeta.DeepCopyInto(&out.ListMeta)
+	if l.Items != nil {
+		out.Items = make([]Object, len(l.Items))
+		for i := range l.Items {
+			l.Items[i].DeepCopyInto(&out.Items[i])
+		}
+	}
+}
+
+func (l *ObjectList) DeepCopy() *ObjectList {
+	if l == nil {
+		return nil
+	}
+	out := new(ObjectList)
+	l.DeepCopyInto(out)
+	return out
+}
+
+func (l *ObjectList) DeepCopyObject() runtime.Object {
+	return l.DeepCopy()
+}
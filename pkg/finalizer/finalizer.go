@@ -0,0 +1,155 @@
+// Licensed to Alexandre VILAIN under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Alexandre VILAIN licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package finalizer runs cleanup handlers before objects are deleted.
+package finalizer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/alexandrevilain/controller-tools/pkg/patch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// Handler cleans up everything related to an object being deleted.
+type Handler interface {
+	// Finalize runs the cleanup for the provided object.
+	// Returning a result with a non-zero RequeueAfter means the cleanup is still in progress,
+	// the finalizer is then kept and the handler is called again later.
+	Finalize(ctx context.Context, obj client.Object) (HandlerResult, error)
+}
+
+// HandlerFunc is a function implementing Handler.
+type HandlerFunc func(ctx context.Context, obj client.Object) (HandlerResult, error)
+
+// Finalize implements Handler.
+func (f HandlerFunc) Finalize(ctx context.Context, obj client.Object) (HandlerResult, error) {
+	return f(ctx, obj)
+}
+
+// HandlerResult is the result of a handler.
+type HandlerResult struct {
+	// RequeueAfter asks to call the handler again after the provided duration.
+	RequeueAfter time.Duration
+}
+
+// Result is the result of the finalizer reconciliation.
+type Result struct {
+	// Deleting is true when the object is being deleted.
+	// The caller should stop reconciling the object when it's true.
+	Deleting bool
+	// RequeueAfter is set when a handler asked to be called again later.
+	RequeueAfter time.Duration
+}
+
+type namedHandler struct {
+	name    string
+	handler Handler
+}
+
+// Finalizer adds a finalizer to objects and runs the registered handlers when they are deleted.
+type Finalizer struct {
+	client   client.Client
+	name     string
+	handlers []namedHandler
+	options  []patch.Option
+}
+
+// New returns a new finalizer with the provided name, using the provided client to patch objects.
+// The provided patch options are used when adding or removing the finalizer.
+func New(name string, c client.Client, opts ...patch.Option) *Finalizer {
+	return &Finalizer{
+		client:  c,
+		name:    name,
+		options: opts,
+	}
+}
+
+// Name returns the finalizer name set on objects.
+func (f *Finalizer) Name() string {
+	return f.name
+}
+
+// Register adds a named handler to the finalizer.
+// Handlers are run in their registration order.
+func (f *Finalizer) Register(name string, handler Handler) error {
+	for _, h := range f.handlers {
+		if h.name == name {
+			return fmt.Errorf("handler %s is already registered", name)
+		}
+	}
+
+	f.handlers = append(f.handlers, namedHandler{
+		name:    name,
+		handler: handler,
+	})
+	return nil
+}
+
+// Reconcile ensures the finalizer is set on the provided object while it's not being deleted.
+// Once the object is being deleted, it runs the registered handlers in order, and removes the finalizer
+// only when all handlers succeeded. A handler asking to be requeued stops the run; following handlers
+// are run on a later reconciliation.
+// The provided object is updated with the api server response.
+func (f *Finalizer) Reconcile(ctx context.Context, obj client.Object) (Result, error) {
+	logger := log.FromContext(ctx)
+
+	helper, err := patch.NewHelper(obj, f.client)
+	if err != nil {
+		return Result{}, err
+	}
+
+	opts := append([]patch.Option{patch.WithWriteBack{}}, f.options...)
+
+	if obj.GetDeletionTimestamp().IsZero() {
+		if controllerutil.AddFinalizer(obj, f.name) {
+			if _, err := helper.Patch(ctx, obj, opts...); err != nil {
+				return Result{}, fmt.Errorf("can't add finalizer: %w", err)
+			}
+		}
+		return Result{}, nil
+	}
+
+	result := Result{Deleting: true}
+	if !controllerutil.ContainsFinalizer(obj, f.name) {
+		return result, nil
+	}
+
+	for _, h := range f.handlers {
+		handlerResult, err := h.handler.Finalize(ctx, obj)
+		if err != nil {
+			return result, fmt.Errorf("can't run %s finalizer handler: %w", h.name, err)
+		}
+
+		if handlerResult.RequeueAfter > 0 {
+			logger.Info("Finalizer handler requested requeue", "handler", h.name, "after", handlerResult.RequeueAfter)
+			result.RequeueAfter = handlerResult.RequeueAfter
+			return result, nil
+		}
+	}
+
+	controllerutil.RemoveFinalizer(obj, f.name)
+	if _, err := helper.Patch(ctx, obj, opts...); err != nil {
+		return result, fmt.Errorf("can't remove finalizer: %w", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,154 @@
+// Licensed to Alexandre VILAIN under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Alexandre VILAIN licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package finalizer_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alexandrevilain/controller-tools/pkg/finalizer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const finalizerName = "test.controller-tools.alexandrevilain.dev/finalizer"
+
+func TestRegister(t *testing.T) {
+	f := finalizer.New(finalizerName, nil)
+
+	noop := finalizer.HandlerFunc(func(context.Context, client.Object) (finalizer.HandlerResult, error) {
+		return finalizer.HandlerResult{}, nil
+	})
+
+	require.NoError(t, f.Register("first", noop))
+	assert.EqualError(t, f.Register("first", noop), "handler first is already registered")
+}
+
+func TestReconcile(t *testing.T) {
+	tests := map[string]struct {
+		deleted bool
+		// handlers are registered in order, their return values are set by handler name.
+		handlers          []string
+		handlerErrors     map[string]error
+		handlerRequeues   map[string]time.Duration
+		expectedCalls     []string
+		expectedResult    finalizer.Result
+		expectedErr       string
+		expectedFinalizer bool
+		expectedGone      bool
+	}{
+		"adds the finalizer": {
+			handlers:          []string{"first"},
+			expectedResult:    finalizer.Result{},
+			expectedFinalizer: true,
+		},
+		"runs handlers in order and removes the finalizer": {
+			deleted:        true,
+			handlers:       []string{"first", "second"},
+			expectedCalls:  []string{"first", "second"},
+			expectedResult: finalizer.Result{Deleting: true},
+			expectedGone:   true,
+		},
+		"keeps the finalizer when a handler fails": {
+			deleted:           true,
+			handlers:          []string{"first", "second", "third"},
+			handlerErrors:     map[string]error{"second": errors.New("boom")},
+			expectedCalls:     []string{"first", "second"},
+			expectedResult:    finalizer.Result{Deleting: true},
+			expectedErr:       "can't run second finalizer handler: boom",
+			expectedFinalizer: true,
+		},
+		"keeps the finalizer when a handler asks to requeue": {
+			deleted:           true,
+			handlers:          []string{"first", "second", "third"},
+			handlerRequeues:   map[string]time.Duration{"second": time.Minute},
+			expectedCalls:     []string{"first", "second"},
+			expectedResult:    finalizer.Result{Deleting: true, RequeueAfter: time.Minute},
+			expectedFinalizer: true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(tt *testing.T) {
+			scheme := runtime.NewScheme()
+			utilruntime.Must(appsv1.AddToScheme(scheme))
+
+			ctx := context.Background()
+			fakeClient := clientfake.NewClientBuilder().WithScheme(scheme).Build()
+
+			object := &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test",
+					Namespace: "test-namespace",
+				},
+			}
+			if test.deleted {
+				object.Finalizers = []string{finalizerName}
+			}
+			require.NoError(tt, fakeClient.Create(ctx, object))
+
+			if test.deleted {
+				require.NoError(tt, fakeClient.Delete(ctx, object))
+				require.NoError(tt, fakeClient.Get(ctx, client.ObjectKeyFromObject(object), object))
+			}
+
+			calls := []string{}
+			f := finalizer.New(finalizerName, fakeClient)
+			for _, handlerName := range test.handlers {
+				handlerName := handlerName
+				err := f.Register(handlerName, finalizer.HandlerFunc(func(context.Context, client.Object) (finalizer.HandlerResult, error) {
+					calls = append(calls, handlerName)
+					return finalizer.HandlerResult{RequeueAfter: test.handlerRequeues[handlerName]}, test.handlerErrors[handlerName]
+				}))
+				require.NoError(tt, err)
+			}
+
+			result, err := f.Reconcile(ctx, object)
+			if test.expectedErr != "" {
+				assert.EqualError(tt, err, test.expectedErr)
+			} else {
+				require.NoError(tt, err)
+			}
+			assert.Equal(tt, test.expectedResult, result)
+			if test.expectedCalls != nil {
+				assert.Equal(tt, test.expectedCalls, calls)
+			} else {
+				assert.Empty(tt, calls)
+			}
+
+			after := &appsv1.Deployment{}
+			err = fakeClient.Get(ctx, client.ObjectKeyFromObject(object), after)
+			if test.expectedGone {
+				assert.True(tt, apierrors.IsNotFound(err))
+				return
+			}
+			require.NoError(tt, err)
+			assert.Equal(tt, test.expectedFinalizer, controllerutil.ContainsFinalizer(after, finalizerName))
+			assert.Equal(tt, after.Finalizers, object.Finalizers)
+		})
+	}
+}
@@ -17,7 +17,15 @@
 
 package patch
 
-import "reflect"
+import (
+	"reflect"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
 
 // isNil returns an error if the passed interface is equal to nil or if it has an interface value of nil.
 func isNil(i interface{}) bool {
@@ -30,3 +38,71 @@ func isNil(i interface{}) bool {
 	}
 	return false
 }
+
+// toUnstructured converts the provided object to its unstructured representation.
+func toUnstructured(obj client.Object) (map[string]interface{}, error) {
+	return runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+}
+
+// fromUnstructured converts the provided unstructured representation to a new object of the same type as like.
+func fromUnstructured(u map[string]interface{}, like client.Object) (client.Object, error) {
+	obj := reflect.New(reflect.TypeOf(like).Elem()).Interface().(client.Object)
+	if unstructuredObj, ok := obj.(runtime.Unstructured); ok {
+		unstructuredObj.SetUnstructuredContent(u)
+		return obj, nil
+	}
+
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u, obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// withFields returns a copy of base with the provided fields set to their value in after.
+// Fields missing in after are removed.
+func withFields(base client.Object, after map[string]interface{}, fields [][]string) (client.Object, error) {
+	u, err := toUnstructured(base)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, field := range fields {
+		value, found, err := unstructured.NestedFieldCopy(after, field...)
+		if err != nil {
+			return nil, err
+		}
+
+		if !found {
+			unstructured.RemoveNestedField(u, field...)
+			continue
+		}
+
+		if err := unstructured.SetNestedField(u, value, field...); err != nil {
+			return nil, err
+		}
+	}
+
+	return fromUnstructured(u, base)
+}
+
+// toPartialObjectMetadata returns the metadata of the provided object as a PartialObjectMetadata.
+func toPartialObjectMetadata(obj client.Object, gvk schema.GroupVersionKind) (*metav1.PartialObjectMetadata, error) {
+	u, err := toUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	partial := &metav1.PartialObjectMetadata{}
+	partial.SetGroupVersionKind(gvk)
+
+	metadata, _, err := unstructured.NestedMap(u, "metadata")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(metadata, &partial.ObjectMeta); err != nil {
+		return nil, err
+	}
+
+	return partial, nil
+}
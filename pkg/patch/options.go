@@ -0,0 +1,90 @@
+// Licensed to Alexandre VILAIN under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Alexandre VILAIN licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package patch
+
+// Option is some configuration that modifies options for a patch request.
+type Option interface {
+	// ApplyToHelper applies this configuration to the given helper options.
+	ApplyToHelper(*HelperOptions)
+}
+
+// HelperOptions contains options for the patch helper.
+type HelperOptions struct {
+	// OwnedConditions defines condition types owned by the controller.
+	// In case of conflicts on owned conditions, the patch helper always uses the value provided by the controller.
+	OwnedConditions []string
+	// Strategy is the patch strategy used to send changes to the api server.
+	// Defaults to MergePatchStrategy.
+	Strategy Strategy
+	// OptimisticLock makes the api server reject patches when the object was changed since it was read.
+	OptimisticLock bool
+	// WriteBack makes Patch write the object returned by the api server into the provided object.
+	WriteBack bool
+	// PartialObjectMetadata makes metadata and finalizers changes sent using PartialObjectMetadata objects.
+	PartialObjectMetadata bool
+}
+
+// WithOwnedConditions allows to define condition types owned by the controller.
+// In case of conflicts on owned conditions, the patch helper always uses the value provided by the controller.
+type WithOwnedConditions struct {
+	Conditions []string
+}
+
+// ApplyToHelper applies this configuration to the given helper options.
+func (w WithOwnedConditions) ApplyToHelper(in *HelperOptions) {
+	in.OwnedConditions = w.Conditions
+}
+
+// WithStrategy allows to define the patch strategy used to send changes to the api server.
+type WithStrategy struct {
+	Strategy Strategy
+}
+
+// ApplyToHelper applies this configuration to the given helper options.
+func (w WithStrategy) ApplyToHelper(in *HelperOptions) {
+	in.Strategy = w.Strategy
+}
+
+// WithOptimisticLock makes the api server reject patches with a conflict when
+// the object was changed since it was read by the helper.
+type WithOptimisticLock struct{}
+
+// ApplyToHelper applies this configuration to the given helper options.
+func (w WithOptimisticLock) ApplyToHelper(in *HelperOptions) {
+	in.OptimisticLock = true
+}
+
+// WithWriteBack makes Patch write the object returned by the api server, including its
+// new resource version and generation, into the provided object.
+// Nothing is written back when any request fails.
+type WithWriteBack struct{}
+
+// ApplyToHelper applies this configuration to the given helper options.
+func (w WithWriteBack) ApplyToHelper(in *HelperOptions) {
+	in.WriteBack = true
+}
+
+// WithPartialObjectMetadata makes metadata and finalizers changes sent using PartialObjectMetadata objects,
+// so that they don't depend on the object's typed representation.
+// The object's GVK must be known by the client's scheme.
+type WithPartialObjectMetadata struct{}
+
+// ApplyToHelper applies this configuration to the given helper options.
+func (w WithPartialObjectMetadata) ApplyToHelper(in *HelperOptions) {
+	in.PartialObjectMetadata = true
+}
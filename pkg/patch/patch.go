@@ -23,10 +23,13 @@ import (
 	"fmt"
 	"reflect"
 
-	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"github.com/alexandrevilain/controller-tools/pkg/conditions"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 )
 
 // Helper is a utility for ensuring the proper patching of objects and their status.
@@ -49,58 +52,296 @@ func NewHelper(obj client.Object, crClient client.Client) (*Helper, error) {
 	}, nil
 }
 
+// Result describes which parts of an object were sent to the api server by Patch.
+type Result struct {
+	// MetadataPatched is true when the object labels, annotations, owner references or finalizers were patched.
+	MetadataPatched bool
+	// SpecPatched is true when the object, without its metadata and status, was patched.
+	SpecPatched bool
+	// StatusPatched is true when the object status was patched.
+	StatusPatched bool
+}
+
+// Changed returns true if anything was sent to the api server.
+func (r Result) Changed() bool {
+	return r.MetadataPatched || r.SpecPatched || r.StatusPatched
+}
+
 // Patch will attempt to patch the provided resource and its status.
-// Provided object is not mutated with the api server result.
-func (h *Helper) Patch(ctx context.Context, afterObject client.Object) error {
+// It returns which parts of the object were sent to the api server.
+// Provided object is not mutated with the api server result, unless WithWriteBack is used and all requests succeeded.
+//
+// Changes are sent in separate requests: metadata (labels, annotations and owner references), then
+// spec and finally status. Finalizers are sent in their own request, first when finalizers are added
+// so that the object is protected before anything else happens, and last when finalizers are only
+// removed so that the object can't be deleted before other changes are persisted.
+// If adding finalizers fails, nothing else is sent. If any other request fails, finalizers are not removed.
+//
+// If the object exposes its conditions (see conditions.Setter), conditions changes are merged
+// with the latest version of the object on the api server, keyed by condition type, so that conditions
+// written concurrently by other controllers are not overwritten. A conditions.ConflictError is returned
+// when a condition changed by the caller was also changed by another writer, unless the caller owns it
+// (see WithOwnedConditions).
+func (h *Helper) Patch(ctx context.Context, afterObject client.Object, opts ...Option) (Result, error) {
+	result := Result{}
 	if isNil(afterObject) {
-		return errors.New("provided object is nil")
+		return result, errors.New("provided object is nil")
 	}
 
-	before, beforeStatus, err := splitObjectAndStatus(h.beforeObject)
+	options := &HelperOptions{}
+	for _, opt := range opts {
+		opt.ApplyToHelper(options)
+	}
+
+	before, err := toUnstructured(h.beforeObject)
 	if err != nil {
-		return err
+		return result, err
 	}
 
-	after, afterStatus, err := splitObjectAndStatus(afterObject)
+	after, err := toUnstructured(afterObject)
 	if err != nil {
-		return err
+		return result, err
+	}
+
+	finalizersAdded, finalizersRemoved := diffFinalizers(h.beforeObject.GetFinalizers(), afterObject.GetFinalizers())
+
+	phases := []phase{}
+	if finalizersAdded {
+		phases = append(phases, finalizersPhase)
+	}
+	phases = append(phases, metadataPhase, specPhase(before, after), statusPhase)
+	if !finalizersAdded && finalizersRemoved {
+		phases = append(phases, finalizersPhase)
 	}
 
 	var errs []error
 
-	if !reflect.DeepEqual(before, after) {
-		obj := afterObject.DeepCopyObject().(client.Object)
-		if err := h.client.Patch(ctx, obj, client.MergeFrom(h.beforeObject)); err != nil {
-			errs = append(errs, fmt.Errorf("unable to patch object: %w", err))
+	// latest is the last object returned by the api server.
+	var latest client.Object
+
+	for _, phase := range phases {
+		if !phase.changed(before, after) {
+			continue
+		}
+
+		if phase.name == finalizersPhase.name && finalizersRemoved && !finalizersAdded && len(errs) > 0 {
+			// Keep finalizers as the other changes were not persisted.
+			break
 		}
+
+		obj, err := h.patchPhase(ctx, latest, after, phase, options)
+		if err != nil {
+			errs = append(errs, err)
+			if phase.name == finalizersPhase.name && finalizersAdded {
+				break
+			}
+			continue
+		}
+
+		switch phase.name {
+		case finalizersPhase.name, metadataPhase.name:
+			result.MetadataPatched = true
+		case statusPhase.name:
+			result.StatusPatched = true
+		default:
+			result.SpecPatched = true
+		}
+		latest = obj
 	}
 
-	if beforeStatus != nil && afterStatus != nil && !reflect.DeepEqual(beforeStatus, afterStatus) {
-		obj := afterObject.DeepCopyObject().(client.Object)
-		if err := h.client.Status().Patch(ctx, obj, client.MergeFrom(h.beforeObject)); err != nil {
-			errs = append(errs, fmt.Errorf("unable to patch object status: %w", err))
+	if options.WriteBack && latest != nil && len(errs) == 0 {
+		// The provided object becomes the new reference, so the next patch only sends new changes.
+		// It's left untouched when a request failed, so that unsent changes are sent by the next patch.
+		reflect.ValueOf(afterObject).Elem().Set(reflect.ValueOf(latest).Elem())
+		h.beforeObject = latest.DeepCopyObject().(client.Object)
+	}
+
+	return result, kerrors.Reduce(kerrors.NewAggregate(errs))
+}
+
+// patchPhase sends the changes of the provided phase.
+// Changes are computed from the helper's object, so that fields changed by other writers since it was read
+// are kept. Only the resource version of latest, the last object returned by the api server if any,
+// is carried forward for optimistic locking.
+// It returns the object as returned by the api server.
+func (h *Helper) patchPhase(ctx context.Context, latest client.Object, after map[string]interface{}, phase phase, options *HelperOptions) (client.Object, error) {
+	base := h.beforeObject.DeepCopyObject().(client.Object)
+	if latest != nil {
+		base.SetResourceVersion(latest.GetResourceVersion())
+	}
+
+	obj, err := withFields(base, after, phase.fields)
+	if err != nil {
+		return nil, err
+	}
+
+	if phase.name == statusPhase.name {
+		merged, err := h.mergeConditions(ctx, obj, options)
+		if err != nil {
+			return nil, err
+		}
+
+		if merged != nil && options.Strategy == JSONPatchStrategy {
+			// JSON patches address conditions by their index: compute them from the conditions they were merged with,
+			// and make the api server reject them if the object changed again since.
+			base.(conditions.Setter).SetConditions(merged.(conditions.Getter).GetConditions())
+			base.SetResourceVersion(merged.GetResourceVersion())
+			obj.SetResourceVersion(merged.GetResourceVersion())
+
+			lockedOptions := *options
+			lockedOptions.OptimisticLock = true
+			options = &lockedOptions
 		}
 	}
 
-	return kerrors.NewAggregate(errs)
+	if phase.metadata && options.PartialObjectMetadata {
+		return h.patchPartialObjectMetadata(ctx, base, obj, latest, phase, options)
+	}
+
+	patch, err := newPatch(base, options)
+	if err != nil {
+		return nil, err
+	}
+
+	if phase.name == statusPhase.name {
+		err = h.client.Status().Patch(ctx, obj, patch)
+	} else {
+		err = h.client.Patch(ctx, obj, patch)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to patch object %s: %w", phase.name, err)
+	}
+
+	return obj, nil
 }
 
-// splitObjectAndStatus converts provided objects to unstructured object, and remove its status.
-// It returns the object without its status, the status and an error if something went wrong.
-func splitObjectAndStatus(obj client.Object) (map[string]interface{}, interface{}, error) {
-	unstructedObj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+// patchPartialObjectMetadata sends the metadata changes made from base to obj using PartialObjectMetadata objects.
+// It returns latest, or obj if no object was returned by the api server yet, with the metadata returned by the api server.
+func (h *Helper) patchPartialObjectMetadata(ctx context.Context, base, obj, latest client.Object, phase phase, options *HelperOptions) (client.Object, error) {
+	gvk, err := apiutil.GVKForObject(base, h.client.Scheme())
 	if err != nil {
-		return nil, nil, err
+		return nil, fmt.Errorf("unable to get object GVK: %w", err)
 	}
 
-	unstructedObjStatus, statusFound, err := unstructured.NestedFieldCopy(unstructedObj, "status")
+	from, err := toPartialObjectMetadata(base, gvk)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
-	if statusFound {
-		unstructured.RemoveNestedField(unstructedObj, "status")
+	to, err := toPartialObjectMetadata(obj, gvk)
+	if err != nil {
+		return nil, err
 	}
 
-	return unstructedObj, unstructedObjStatus, nil
+	patch, err := newPatch(from, options)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.client.Patch(ctx, to, patch); err != nil {
+		return nil, fmt.Errorf("unable to patch object %s: %w", phase.name, err)
+	}
+
+	if latest != nil {
+		obj = latest
+	}
+
+	u, err := toUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&to.ObjectMeta)
+	if err != nil {
+		return nil, err
+	}
+	u["metadata"] = metadata
+
+	return fromUnstructured(u, obj)
+}
+
+// PatchWithRetry calls mutate then patches the provided object, retrying on conflicts.
+// It returns the result of the last patch attempt.
+// On conflict, the latest version of the object is fetched into obj and becomes the helper's new
+// reference, then mutate is called again to re-apply the caller's changes before patching again.
+// Conflicts are either returned by the api server (e.g. when using WithOptimisticLock) or detected
+// when merging conditions.
+func (h *Helper) PatchWithRetry(ctx context.Context, obj client.Object, mutate func() error, opts ...Option) (Result, error) {
+	result := Result{}
+	if isNil(obj) {
+		return result, errors.New("provided object is nil")
+	}
+
+	attempt := 0
+	err := retry.OnError(retry.DefaultRetry, IsConflict, func() error {
+		if attempt > 0 {
+			if err := h.client.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+				return fmt.Errorf("unable to get latest object: %w", err)
+			}
+			h.beforeObject = obj.DeepCopyObject().(client.Object)
+		}
+		attempt++
+
+		if err := mutate(); err != nil {
+			return err
+		}
+
+		var err error
+		result, err = h.Patch(ctx, obj, opts...)
+		return err
+	})
+
+	return result, err
+}
+
+// IsConflict returns true if the provided error, or any error aggregated in it,
+// is a conflict returned by the api server or a conflict detected while merging conditions.
+func IsConflict(err error) bool {
+	var aggregate kerrors.Aggregate
+	if errors.As(err, &aggregate) {
+		for _, e := range aggregate.Errors() {
+			if IsConflict(e) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var conflictErr *conditions.ConflictError
+	return apierrors.IsConflict(err) || errors.As(err, &conflictErr)
+}
+
+// mergeConditions applies conditions changes made on the provided object on top of the conditions
+// of the latest version of the object, then sets the result on the provided object.
+// It returns the latest version of the object, as fetched before merging, or nil if there was nothing to merge.
+func (h *Helper) mergeConditions(ctx context.Context, obj client.Object, options *HelperOptions) (client.Object, error) {
+	beforeGetter, ok := h.beforeObject.(conditions.Getter)
+	if !ok {
+		return nil, nil
+	}
+
+	afterSetter, ok := obj.(conditions.Setter)
+	if !ok {
+		return nil, nil
+	}
+
+	patch := conditions.NewPatch(beforeGetter, afterSetter)
+	if patch.IsZero() {
+		return nil, nil
+	}
+
+	latest := h.beforeObject.DeepCopyObject().(client.Object)
+	if err := h.client.Get(ctx, client.ObjectKeyFromObject(h.beforeObject), latest); err != nil {
+		return nil, fmt.Errorf("unable to get latest object: %w", err)
+	}
+	fetched := latest.DeepCopyObject().(client.Object)
+
+	latestSetter := latest.(conditions.Setter)
+	if err := patch.Apply(latestSetter, options.OwnedConditions...); err != nil {
+		return nil, fmt.Errorf("unable to merge conditions: %w", err)
+	}
+
+	afterSetter.SetConditions(latestSetter.GetConditions())
+
+	return fetched, nil
 }
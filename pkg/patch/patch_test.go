@@ -19,26 +19,33 @@ package patch_test
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"testing"
 
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 
+	"github.com/alexandrevilain/controller-tools/pkg/conditions"
+	"github.com/alexandrevilain/controller-tools/pkg/fake"
 	"github.com/alexandrevilain/controller-tools/pkg/patch"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 )
 
 func TestHelperPatch(t *testing.T) {
 	tests := map[string]struct {
-		object        client.Object
-		updateObject  func(client.Object)
-		validatePatch func(*testing.T, client.Object)
-		expectedErr   string
+		object         client.Object
+		updateObject   func(client.Object)
+		validatePatch  func(*testing.T, client.Object)
+		expectedResult patch.Result
+		expectedErr    string
 	}{
 		"works with finalizers removed": {
 			object: &appsv1.Deployment{
@@ -53,6 +60,7 @@ func TestHelperPatch(t *testing.T) {
 			updateObject: func(o client.Object) {
 				o.(*appsv1.Deployment).Finalizers = []string{}
 			},
+			expectedResult: patch.Result{MetadataPatched: true},
 			validatePatch: func(tt *testing.T, o client.Object) {
 				assert.Empty(tt, o.GetFinalizers())
 			},
@@ -67,6 +75,7 @@ func TestHelperPatch(t *testing.T) {
 			updateObject: func(o client.Object) {
 				o.(*appsv1.Deployment).Finalizers = []string{"my.test/finalizer"}
 			},
+			expectedResult: patch.Result{MetadataPatched: true},
 			validatePatch: func(tt *testing.T, o client.Object) {
 				assert.Len(tt, o.GetFinalizers(), 1)
 				assert.Equal(tt, o.GetFinalizers(), []string{"my.test/finalizer"})
@@ -84,6 +93,7 @@ func TestHelperPatch(t *testing.T) {
 					ObservedGeneration: 42,
 				}
 			},
+			expectedResult: patch.Result{StatusPatched: true},
 			validatePatch: func(tt *testing.T, o client.Object) {
 				assert.Equal(tt, o.(*appsv1.Deployment).Status.ObservedGeneration, int64(42))
 			},
@@ -100,10 +110,22 @@ func TestHelperPatch(t *testing.T) {
 					Paused: true,
 				}
 			},
+			expectedResult: patch.Result{SpecPatched: true},
 			validatePatch: func(tt *testing.T, o client.Object) {
 				assert.Equal(tt, o.(*appsv1.Deployment).Spec.Paused, true)
 			},
 		},
+		"sends nothing without changes": {
+			object: &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-cluster",
+					Namespace: "test-namespace",
+				},
+			},
+			updateObject:   func(o client.Object) {},
+			expectedResult: patch.Result{},
+			validatePatch:  func(tt *testing.T, o client.Object) {},
+		},
 		"works with both spec and status update": {
 			object: &appsv1.Deployment{
 				ObjectMeta: metav1.ObjectMeta{
@@ -119,6 +141,7 @@ func TestHelperPatch(t *testing.T) {
 					ObservedGeneration: 42,
 				}
 			},
+			expectedResult: patch.Result{SpecPatched: true, StatusPatched: true},
 			validatePatch: func(tt *testing.T, o client.Object) {
 				assert.Equal(tt, o.(*appsv1.Deployment).Spec.Paused, true)
 				assert.Equal(tt, o.(*appsv1.Deployment).Status.ObservedGeneration, int64(42))
@@ -131,7 +154,7 @@ func TestHelperPatch(t *testing.T) {
 			utilruntime.Must(appsv1.AddToScheme(scheme))
 
 			ctx := context.Background()
-			fakeClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+			fakeClient := clientfake.NewClientBuilder().WithScheme(scheme).Build()
 			err := fakeClient.Create(ctx, test.object)
 			require.NoError(tt, err)
 
@@ -141,11 +164,13 @@ func TestHelperPatch(t *testing.T) {
 			}
 			test.updateObject(test.object)
 
-			patchErr := h.Patch(ctx, test.object)
+			result, patchErr := h.Patch(ctx, test.object)
 			if test.expectedErr != "" {
 				assert.Error(tt, patchErr)
 				assert.EqualError(tt, patchErr, test.expectedErr)
 			} else {
+				assert.Equal(tt, test.expectedResult, result)
+
 				after := &appsv1.Deployment{}
 				err = fakeClient.Get(ctx, client.ObjectKeyFromObject(test.object), after)
 				require.NoError(tt, err)
@@ -155,3 +180,603 @@ func TestHelperPatch(t *testing.T) {
 		})
 	}
 }
+
+func TestHelperPatchConditions(t *testing.T) {
+	tests := map[string]struct {
+		// updateLatest simulates a concurrent change made by another controller.
+		updateLatest       func(*fake.Object)
+		updateObject       func(*fake.Object)
+		options            []patch.Option
+		expectedConditions map[string]metav1.ConditionStatus
+		expectedErr        string
+	}{
+		"keeps conditions added concurrently": {
+			updateLatest: func(o *fake.Object) {
+				conditions.MarkTrue(o, "Other", "OK", "")
+			},
+			updateObject: func(o *fake.Object) {
+				conditions.MarkTrue(o, "Mine", "OK", "")
+			},
+			expectedConditions: map[string]metav1.ConditionStatus{
+				"Initial": metav1.ConditionTrue,
+				"Other":   metav1.ConditionTrue,
+				"Mine":    metav1.ConditionTrue,
+			},
+		},
+		"keeps conditions changed concurrently": {
+			updateLatest: func(o *fake.Object) {
+				conditions.MarkFalse(o, "Initial", "Broken", "")
+			},
+			updateObject: func(o *fake.Object) {
+				conditions.MarkTrue(o, "Mine", "OK", "")
+			},
+			expectedConditions: map[string]metav1.ConditionStatus{
+				"Initial": metav1.ConditionFalse,
+				"Mine":    metav1.ConditionTrue,
+			},
+		},
+		"removes conditions": {
+			updateLatest: func(o *fake.Object) {
+				conditions.MarkTrue(o, "Other", "OK", "")
+			},
+			updateObject: func(o *fake.Object) {
+				conditions.Delete(o, "Initial")
+			},
+			expectedConditions: map[string]metav1.ConditionStatus{
+				"Other": metav1.ConditionTrue,
+			},
+		},
+		"keeps conditions added concurrently with JSON patches": {
+			updateLatest: func(o *fake.Object) {
+				conditions.MarkTrue(o, "Other", "OK", "")
+			},
+			updateObject: func(o *fake.Object) {
+				conditions.MarkTrue(o, "Mine", "OK", "")
+			},
+			options: []patch.Option{patch.WithStrategy{Strategy: patch.JSONPatchStrategy}},
+			expectedConditions: map[string]metav1.ConditionStatus{
+				"Initial": metav1.ConditionTrue,
+				"Other":   metav1.ConditionTrue,
+				"Mine":    metav1.ConditionTrue,
+			},
+		},
+		"removes conditions with JSON patches": {
+			updateLatest: func(o *fake.Object) {
+				conditions.MarkTrue(o, "Other", "OK", "")
+			},
+			updateObject: func(o *fake.Object) {
+				conditions.Delete(o, "Initial")
+			},
+			options: []patch.Option{patch.WithStrategy{Strategy: patch.JSONPatchStrategy}},
+			expectedConditions: map[string]metav1.ConditionStatus{
+				"Other": metav1.ConditionTrue,
+			},
+		},
+		"returns a conflict error when the same condition changed concurrently": {
+			updateLatest: func(o *fake.Object) {
+				conditions.MarkFalse(o, "Initial", "Broken", "")
+			},
+			updateObject: func(o *fake.Object) {
+				conditions.MarkUnknown(o, "Initial", "Checking", "")
+			},
+			expectedErr: "unable to merge conditions: condition Initial was modified concurrently",
+		},
+		"overwrites owned conditions changed concurrently": {
+			updateLatest: func(o *fake.Object) {
+				conditions.MarkFalse(o, "Initial", "Broken", "")
+			},
+			updateObject: func(o *fake.Object) {
+				conditions.MarkUnknown(o, "Initial", "Checking", "")
+			},
+			options: []patch.Option{patch.WithOwnedConditions{Conditions: []string{"Initial"}}},
+			expectedConditions: map[string]metav1.ConditionStatus{
+				"Initial": metav1.ConditionUnknown,
+			},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(tt *testing.T) {
+			scheme := runtime.NewScheme()
+			utilruntime.Must(fake.AddToScheme(scheme))
+
+			ctx := context.Background()
+			fakeClient := clientfake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&fake.Object{}).Build()
+
+			object := fake.NewObject("test", "test-namespace")
+			conditions.MarkTrue(object, "Initial", "OK", "")
+			require.NoError(tt, fakeClient.Create(ctx, object))
+			require.NoError(tt, fakeClient.Status().Update(ctx, object))
+
+			h, err := patch.NewHelper(object, fakeClient)
+			require.NoError(tt, err)
+
+			latest := &fake.Object{}
+			require.NoError(tt, fakeClient.Get(ctx, client.ObjectKeyFromObject(object), latest))
+			test.updateLatest(latest)
+			require.NoError(tt, fakeClient.Status().Update(ctx, latest))
+
+			test.updateObject(object)
+
+			_, patchErr := h.Patch(ctx, object, test.options...)
+			if test.expectedErr != "" {
+				assert.EqualError(tt, patchErr, test.expectedErr)
+				var conflictErr *conditions.ConflictError
+				assert.ErrorAs(tt, patchErr, &conflictErr)
+				return
+			}
+			require.NoError(tt, patchErr)
+
+			after := &fake.Object{}
+			require.NoError(tt, fakeClient.Get(ctx, client.ObjectKeyFromObject(object), after))
+
+			statuses := map[string]metav1.ConditionStatus{}
+			for _, condition := range after.Status.Conditions {
+				statuses[condition.Type] = condition.Status
+			}
+			assert.Equal(tt, test.expectedConditions, statuses)
+			assert.Len(tt, after.Status.Conditions, len(test.expectedConditions))
+		})
+	}
+}
+
+func TestHelperPatchConditionsChangedWhileMerging(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(fake.AddToScheme(scheme))
+
+	ctx := context.Background()
+	changed := false
+	fakeClient := clientfake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(&fake.Object{}).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				if err := c.Get(ctx, key, obj, opts...); err != nil {
+					return err
+				}
+				if changed {
+					return nil
+				}
+				// Another writer adds a condition right after the helper fetched the object to merge conditions.
+				changed = true
+				other := obj.DeepCopyObject().(*fake.Object)
+				conditions.MarkTrue(other, "Other", "OK", "")
+				return c.Status().Update(ctx, other)
+			},
+		}).
+		Build()
+
+	object := fake.NewObject("test", "test-namespace")
+	conditions.MarkTrue(object, "Initial", "OK", "")
+	require.NoError(t, fakeClient.Create(ctx, object))
+	require.NoError(t, fakeClient.Status().Update(ctx, object))
+
+	h, err := patch.NewHelper(object, fakeClient)
+	require.NoError(t, err)
+
+	conditions.MarkTrue(object, "Mine", "OK", "")
+	_, err = h.Patch(ctx, object, patch.WithStrategy{Strategy: patch.JSONPatchStrategy})
+	require.Error(t, err)
+	assert.True(t, patch.IsConflict(err))
+
+	after := &fake.Object{}
+	require.NoError(t, fakeClient.Get(ctx, client.ObjectKeyFromObject(object), after))
+	conditionTypes := []string{}
+	for _, condition := range after.Status.Conditions {
+		conditionTypes = append(conditionTypes, condition.Type)
+	}
+	assert.Equal(t, []string{"Initial", "Other"}, conditionTypes)
+}
+
+func TestHelperPatchStrategies(t *testing.T) {
+	tests := map[string]struct {
+		options []patch.Option
+		// concurrentUpdate makes the object change on the api server after the helper was created.
+		concurrentUpdate bool
+		expectConflict   bool
+	}{
+		"merge patch": {
+			options: []patch.Option{patch.WithStrategy{Strategy: patch.MergePatchStrategy}},
+		},
+		"json patch": {
+			options: []patch.Option{patch.WithStrategy{Strategy: patch.JSONPatchStrategy}},
+		},
+		"strategic merge patch": {
+			options: []patch.Option{patch.WithStrategy{Strategy: patch.StrategicMergePatchStrategy}},
+		},
+		"merge patch ignores concurrent changes without optimistic lock": {
+			options:          []patch.Option{patch.WithStrategy{Strategy: patch.MergePatchStrategy}},
+			concurrentUpdate: true,
+		},
+		"merge patch with optimistic lock": {
+			options: []patch.Option{patch.WithStrategy{Strategy: patch.MergePatchStrategy}, patch.WithOptimisticLock{}},
+		},
+		"merge patch with optimistic lock conflicts": {
+			options:          []patch.Option{patch.WithStrategy{Strategy: patch.MergePatchStrategy}, patch.WithOptimisticLock{}},
+			concurrentUpdate: true,
+			expectConflict:   true,
+		},
+		"json patch with optimistic lock conflicts": {
+			options:          []patch.Option{patch.WithStrategy{Strategy: patch.JSONPatchStrategy}, patch.WithOptimisticLock{}},
+			concurrentUpdate: true,
+			expectConflict:   true,
+		},
+		"strategic merge patch with optimistic lock conflicts": {
+			options:          []patch.Option{patch.WithStrategy{Strategy: patch.StrategicMergePatchStrategy}, patch.WithOptimisticLock{}},
+			concurrentUpdate: true,
+			expectConflict:   true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(tt *testing.T) {
+			scheme := runtime.NewScheme()
+			utilruntime.Must(appsv1.AddToScheme(scheme))
+
+			ctx := context.Background()
+			fakeClient := clientfake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&appsv1.Deployment{}).Build()
+
+			object := &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-cluster",
+					Namespace: "test-namespace",
+				},
+			}
+			require.NoError(tt, fakeClient.Create(ctx, object))
+
+			h, err := patch.NewHelper(object, fakeClient)
+			require.NoError(tt, err)
+
+			if test.concurrentUpdate {
+				latest := &appsv1.Deployment{}
+				require.NoError(tt, fakeClient.Get(ctx, client.ObjectKeyFromObject(object), latest))
+				latest.Labels = map[string]string{"concurrent": "true"}
+				require.NoError(tt, fakeClient.Update(ctx, latest))
+			}
+
+			object.Spec.Paused = true
+			object.Status.ObservedGeneration = 42
+
+			_, patchErr := h.Patch(ctx, object, test.options...)
+			if test.expectConflict {
+				assert.True(tt, patch.IsConflict(patchErr), "expected a conflict error, got: %v", patchErr)
+				return
+			}
+			require.NoError(tt, patchErr)
+
+			after := &appsv1.Deployment{}
+			require.NoError(tt, fakeClient.Get(ctx, client.ObjectKeyFromObject(object), after))
+			assert.True(tt, after.Spec.Paused)
+			assert.Equal(tt, int64(42), after.Status.ObservedGeneration)
+		})
+	}
+}
+
+func TestHelperPatchWithRetry(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(appsv1.AddToScheme(scheme))
+
+	ctx := context.Background()
+	fakeClient := clientfake.NewClientBuilder().WithScheme(scheme).Build()
+
+	object := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-cluster",
+			Namespace: "test-namespace",
+		},
+	}
+	require.NoError(t, fakeClient.Create(ctx, object))
+
+	h, err := patch.NewHelper(object, fakeClient)
+	require.NoError(t, err)
+
+	latest := &appsv1.Deployment{}
+	require.NoError(t, fakeClient.Get(ctx, client.ObjectKeyFromObject(object), latest))
+	latest.Labels = map[string]string{"concurrent": "true"}
+	require.NoError(t, fakeClient.Update(ctx, latest))
+
+	calls := 0
+	result, err := h.PatchWithRetry(ctx, object, func() error {
+		calls++
+		object.Spec.Paused = true
+		return nil
+	}, patch.WithOptimisticLock{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, patch.Result{SpecPatched: true}, result)
+
+	after := &appsv1.Deployment{}
+	require.NoError(t, fakeClient.Get(ctx, client.ObjectKeyFromObject(object), after))
+	assert.True(t, after.Spec.Paused)
+	assert.Equal(t, map[string]string{"concurrent": "true"}, after.Labels)
+}
+
+func TestHelperPatchWriteBack(t *testing.T) {
+	tests := map[string]struct {
+		options             []patch.Option
+		expectedWrittenBack bool
+	}{
+		"does not mutate the object by default": {},
+		"writes back the api server result": {
+			options:             []patch.Option{patch.WithWriteBack{}},
+			expectedWrittenBack: true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(tt *testing.T) {
+			scheme := runtime.NewScheme()
+			utilruntime.Must(appsv1.AddToScheme(scheme))
+
+			ctx := context.Background()
+			fakeClient := clientfake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&appsv1.Deployment{}).Build()
+
+			object := &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-cluster",
+					Namespace: "test-namespace",
+				},
+			}
+			require.NoError(tt, fakeClient.Create(ctx, object))
+			resourceVersion := object.GetResourceVersion()
+
+			h, err := patch.NewHelper(object, fakeClient)
+			require.NoError(tt, err)
+
+			object.Spec.Paused = true
+			object.Status.ObservedGeneration = 42
+
+			result, err := h.Patch(ctx, object, test.options...)
+			require.NoError(tt, err)
+			assert.Equal(tt, patch.Result{SpecPatched: true, StatusPatched: true}, result)
+
+			latest := &appsv1.Deployment{}
+			require.NoError(tt, fakeClient.Get(ctx, client.ObjectKeyFromObject(object), latest))
+
+			if !test.expectedWrittenBack {
+				assert.Equal(tt, resourceVersion, object.GetResourceVersion())
+				return
+			}
+
+			assert.Equal(tt, latest.GetResourceVersion(), object.GetResourceVersion())
+			assert.True(tt, object.Spec.Paused)
+			assert.Equal(tt, int64(42), object.Status.ObservedGeneration)
+
+			// The written back object is the new reference: patching it again sends nothing.
+			result, err = h.Patch(ctx, object, test.options...)
+			require.NoError(tt, err)
+			assert.False(tt, result.Changed())
+		})
+	}
+}
+
+func TestHelperPatchWriteBackFailure(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(appsv1.AddToScheme(scheme))
+
+	ctx := context.Background()
+	failStatus := true
+	fakeClient := clientfake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(&appsv1.Deployment{}).
+		WithInterceptorFuncs(interceptor.Funcs{
+			SubResourcePatch: func(ctx context.Context, c client.Client, subResource string, obj client.Object, p client.Patch, opts ...client.SubResourcePatchOption) error {
+				if failStatus {
+					return errors.New("status patch failed")
+				}
+				return c.SubResource(subResource).Patch(ctx, obj, p, opts...)
+			},
+		}).
+		Build()
+
+	object := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-cluster",
+			Namespace: "test-namespace",
+		},
+	}
+	require.NoError(t, fakeClient.Create(ctx, object))
+
+	h, err := patch.NewHelper(object, fakeClient)
+	require.NoError(t, err)
+
+	object.Spec.Paused = true
+	object.Status.ObservedGeneration = 42
+
+	result, err := h.Patch(ctx, object, patch.WithWriteBack{})
+	require.ErrorContains(t, err, "status patch failed")
+	assert.Equal(t, patch.Result{SpecPatched: true}, result)
+
+	// Unsent changes are kept in the provided object.
+	assert.True(t, object.Spec.Paused)
+	assert.Equal(t, int64(42), object.Status.ObservedGeneration)
+
+	// And sent by the next patch.
+	failStatus = false
+	result, err = h.Patch(ctx, object, patch.WithWriteBack{})
+	require.NoError(t, err)
+	assert.True(t, result.StatusPatched)
+
+	latest := &appsv1.Deployment{}
+	require.NoError(t, fakeClient.Get(ctx, client.ObjectKeyFromObject(object), latest))
+	assert.True(t, latest.Spec.Paused)
+	assert.Equal(t, int64(42), latest.Status.ObservedGeneration)
+	assert.Equal(t, latest.GetResourceVersion(), object.GetResourceVersion())
+}
+
+func TestHelperPatchKeepsConcurrentChanges(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(corev1.AddToScheme(scheme))
+
+	ctx := context.Background()
+	fakeClient := clientfake.NewClientBuilder().WithScheme(scheme).Build()
+
+	object := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-cluster",
+			Namespace: "test-namespace",
+		},
+		Data: map[string]string{"a": "1", "b": "1"},
+	}
+	require.NoError(t, fakeClient.Create(ctx, object))
+
+	h, err := patch.NewHelper(object, fakeClient)
+	require.NoError(t, err)
+
+	// Another writer changes the object after the helper was created.
+	concurrent := object.DeepCopy()
+	concurrent.Data["b"] = "2"
+	require.NoError(t, fakeClient.Update(ctx, concurrent))
+
+	object.Data["a"] = "2"
+	object.Labels = map[string]string{"app": "test"}
+
+	result, err := h.Patch(ctx, object)
+	require.NoError(t, err)
+	assert.Equal(t, patch.Result{MetadataPatched: true, SpecPatched: true}, result)
+
+	after := &corev1.ConfigMap{}
+	require.NoError(t, fakeClient.Get(ctx, client.ObjectKeyFromObject(object), after))
+	assert.Equal(t, map[string]string{"a": "2", "b": "2"}, after.Data)
+	assert.Equal(t, map[string]string{"app": "test"}, after.Labels)
+}
+
+// recordingClient records the kind of every patch request sent to the api server.
+type recordingClient struct {
+	client.Client
+	requests []string
+}
+
+func (c *recordingClient) Patch(ctx context.Context, obj client.Object, p client.Patch, opts ...client.PatchOption) error {
+	data, err := p.Data(obj)
+	if err != nil {
+		return err
+	}
+	c.requests = append(c.requests, fmt.Sprintf("%T %s", obj, data))
+	return c.Client.Patch(ctx, obj, p, opts...)
+}
+
+func (c *recordingClient) Status() client.SubResourceWriter {
+	return &recordingStatusWriter{SubResourceWriter: c.Client.Status(), client: c}
+}
+
+type recordingStatusWriter struct {
+	client.SubResourceWriter
+	client *recordingClient
+}
+
+func (w *recordingStatusWriter) Patch(ctx context.Context, obj client.Object, p client.Patch, opts ...client.SubResourcePatchOption) error {
+	data, err := p.Data(obj)
+	if err != nil {
+		return err
+	}
+	w.client.requests = append(w.client.requests, fmt.Sprintf("status %s", data))
+	return w.SubResourceWriter.Patch(ctx, obj, p, opts...)
+}
+
+func TestHelperPatchPhases(t *testing.T) {
+	tests := map[string]struct {
+		finalizers       []string
+		updateObject     func(*appsv1.Deployment)
+		options          []patch.Option
+		expectedRequests []string
+		expectedResult   patch.Result
+	}{
+		"sends metadata only": {
+			updateObject: func(d *appsv1.Deployment) {
+				d.Labels = map[string]string{"app": "test"}
+			},
+			expectedRequests: []string{
+				`*v1.Deployment {"metadata":{"labels":{"app":"test"}}}`,
+			},
+			expectedResult: patch.Result{MetadataPatched: true},
+		},
+		"sends metadata, spec then status": {
+			updateObject: func(d *appsv1.Deployment) {
+				d.Labels = map[string]string{"app": "test"}
+				d.Spec.Paused = true
+				d.Status.ObservedGeneration = 42
+			},
+			expectedRequests: []string{
+				`*v1.Deployment {"metadata":{"labels":{"app":"test"}}}`,
+				`*v1.Deployment {"spec":{"paused":true}}`,
+				`status {"status":{"observedGeneration":42}}`,
+			},
+			expectedResult: patch.Result{MetadataPatched: true, SpecPatched: true, StatusPatched: true},
+		},
+		"sends added finalizers first": {
+			updateObject: func(d *appsv1.Deployment) {
+				d.Finalizers = []string{"my.test/finalizer"}
+				d.Spec.Paused = true
+			},
+			expectedRequests: []string{
+				`*v1.Deployment {"metadata":{"finalizers":["my.test/finalizer"]}}`,
+				`*v1.Deployment {"spec":{"paused":true}}`,
+			},
+			expectedResult: patch.Result{MetadataPatched: true, SpecPatched: true},
+		},
+		"sends removed finalizers last": {
+			finalizers: []string{"my.test/finalizer"},
+			updateObject: func(d *appsv1.Deployment) {
+				d.Finalizers = nil
+				d.Spec.Paused = true
+				d.Status.ObservedGeneration = 42
+			},
+			expectedRequests: []string{
+				`*v1.Deployment {"spec":{"paused":true}}`,
+				`status {"status":{"observedGeneration":42}}`,
+				`*v1.Deployment {"metadata":{"finalizers":null}}`,
+			},
+			expectedResult: patch.Result{MetadataPatched: true, SpecPatched: true, StatusPatched: true},
+		},
+		"sends metadata using partial object metadata": {
+			updateObject: func(d *appsv1.Deployment) {
+				d.Finalizers = []string{"my.test/finalizer"}
+				d.Annotations = map[string]string{"test": "true"}
+				d.Spec.Paused = true
+			},
+			options: []patch.Option{patch.WithPartialObjectMetadata{}},
+			expectedRequests: []string{
+				`*v1.PartialObjectMetadata {"metadata":{"finalizers":["my.test/finalizer"]}}`,
+				`*v1.PartialObjectMetadata {"metadata":{"annotations":{"test":"true"}}}`,
+				`*v1.Deployment {"spec":{"paused":true}}`,
+			},
+			expectedResult: patch.Result{MetadataPatched: true, SpecPatched: true},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(tt *testing.T) {
+			scheme := runtime.NewScheme()
+			utilruntime.Must(appsv1.AddToScheme(scheme))
+
+			ctx := context.Background()
+			recorder := &recordingClient{
+				Client: clientfake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&appsv1.Deployment{}).Build(),
+			}
+
+			object := &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       "test-cluster",
+					Namespace:  "test-namespace",
+					Finalizers: test.finalizers,
+				},
+			}
+			require.NoError(tt, recorder.Create(ctx, object))
+
+			h, err := patch.NewHelper(object, recorder)
+			require.NoError(tt, err)
+
+			test.updateObject(object)
+
+			result, err := h.Patch(ctx, object, test.options...)
+			require.NoError(tt, err)
+			assert.Equal(tt, test.expectedResult, result)
+			assert.Equal(tt, test.expectedRequests, recorder.requests)
+
+			after := &appsv1.Deployment{}
+			require.NoError(tt, recorder.Get(ctx, client.ObjectKeyFromObject(object), after))
+			assert.Equal(tt, object.Spec.Paused, after.Spec.Paused)
+			assert.Equal(tt, object.Status.ObservedGeneration, after.Status.ObservedGeneration)
+			assert.Equal(tt, object.Labels, after.Labels)
+			assert.Equal(tt, object.Annotations, after.Annotations)
+			assert.ElementsMatch(tt, object.Finalizers, after.Finalizers)
+		})
+	}
+}
@@ -0,0 +1,99 @@
+// Licensed to Alexandre VILAIN under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Alexandre VILAIN licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package patch
+
+import (
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// phase is a set of fields sent to the api server in a single request.
+type phase struct {
+	name string
+	// fields are the paths of the fields sent by this phase.
+	fields [][]string
+	// metadata is true when the phase only contains metadata fields.
+	metadata bool
+}
+
+var (
+	finalizersPhase = phase{
+		name:     "finalizers",
+		fields:   [][]string{{"metadata", "finalizers"}},
+		metadata: true,
+	}
+	metadataPhase = phase{
+		name: "metadata",
+		fields: [][]string{
+			{"metadata", "labels"},
+			{"metadata", "annotations"},
+			{"metadata", "ownerReferences"},
+		},
+		metadata: true,
+	}
+	statusPhase = phase{
+		name:   "status",
+		fields: [][]string{{"status"}},
+	}
+)
+
+// specPhase returns the phase sending all top-level fields of the provided objects,
+// except their type, metadata and status.
+func specPhase(before, after map[string]interface{}) phase {
+	keys := sets.New[string]()
+	for _, obj := range []map[string]interface{}{before, after} {
+		for key := range obj {
+			switch key {
+			case "apiVersion", "kind", "metadata", "status":
+			default:
+				keys.Insert(key)
+			}
+		}
+	}
+
+	fields := [][]string{}
+	for _, key := range sets.List(keys) {
+		fields = append(fields, []string{key})
+	}
+
+	return phase{
+		name:   "spec",
+		fields: fields,
+	}
+}
+
+// changed returns true if any field of the phase differs between the provided objects.
+func (p phase) changed(before, after map[string]interface{}) bool {
+	for _, field := range p.fields {
+		beforeValue, _, _ := unstructured.NestedFieldNoCopy(before, field...)
+		afterValue, _, _ := unstructured.NestedFieldNoCopy(after, field...)
+		if !reflect.DeepEqual(beforeValue, afterValue) {
+			return true
+		}
+	}
+	return false
+}
+
+// diffFinalizers returns whether finalizers were added or removed between before and after.
+func diffFinalizers(before, after []string) (added, removed bool) {
+	beforeSet := sets.New(before...)
+	afterSet := sets.New(after...)
+	return afterSet.Difference(beforeSet).Len() > 0, beforeSet.Difference(afterSet).Len() > 0
+}
@@ -0,0 +1,98 @@
+// Licensed to Alexandre VILAIN under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Alexandre VILAIN licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package patch
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gomodules.xyz/jsonpatch/v2"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Strategy defines how changes are sent to the api server.
+type Strategy string
+
+const (
+	// MergePatchStrategy sends changes as a JSON merge patch (RFC 7386).
+	// Lists are replaced as a whole.
+	MergePatchStrategy Strategy = "Merge"
+	// JSONPatchStrategy sends changes as a JSON patch (RFC 6902).
+	// Status patches merging conditions always use an optimistic lock, as list items are addressed by index.
+	JSONPatchStrategy Strategy = "JSONPatch"
+	// StrategicMergePatchStrategy sends changes as a strategic merge patch.
+	// It's only supported by built-in types.
+	StrategicMergePatchStrategy Strategy = "StrategicMerge"
+)
+
+// newPatch returns a patch computing changes from the provided object using the configured strategy.
+func newPatch(from client.Object, options *HelperOptions) (client.Patch, error) {
+	mergeOptions := []client.MergeFromOption{}
+	if options.OptimisticLock {
+		mergeOptions = append(mergeOptions, client.MergeFromWithOptimisticLock{})
+	}
+
+	switch options.Strategy {
+	case "", MergePatchStrategy:
+		return client.MergeFromWithOptions(from, mergeOptions...), nil
+	case StrategicMergePatchStrategy:
+		return client.StrategicMergeFrom(from, mergeOptions...), nil
+	case JSONPatchStrategy:
+		return &jsonPatch{from: from, optimisticLock: options.OptimisticLock}, nil
+	default:
+		return nil, fmt.Errorf("unknown patch strategy: %s", options.Strategy)
+	}
+}
+
+// jsonPatch is a client.Patch computing a JSON patch (RFC 6902) between two objects.
+type jsonPatch struct {
+	from           client.Object
+	optimisticLock bool
+}
+
+// Type implements client.Patch.
+func (p *jsonPatch) Type() types.PatchType {
+	return types.JSONPatchType
+}
+
+// Data implements client.Patch.
+func (p *jsonPatch) Data(obj client.Object) ([]byte, error) {
+	original, err := json.Marshal(p.from)
+	if err != nil {
+		return nil, err
+	}
+
+	modified, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	operations, err := jsonpatch.CreatePatch(original, modified)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.optimisticLock {
+		// Sending the resource version makes the api server reject the patch
+		// with a conflict if the object changed since it was read.
+		operations = append(operations, jsonpatch.NewOperation("replace", "/metadata/resourceVersion", p.from.GetResourceVersion()))
+	}
+
+	return json.Marshal(operations)
+}
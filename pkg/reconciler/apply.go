@@ -0,0 +1,106 @@
+// Licensed to Alexandre VILAIN under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Alexandre VILAIN licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package reconciler
+
+import (
+	"context"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// ApplyMode defines how the reconciler writes resources to the api server.
+type ApplyMode string
+
+const (
+	// ApplyModeUpdate fetches the live object, mutates it using the builder
+	// and updates it when it differs from the live object.
+	ApplyModeUpdate ApplyMode = "Update"
+	// ApplyModeServerSide applies the object produced by the builder using server-side apply.
+	// The builder's object is sent as a partial intent: only the fields it sets are owned by the reconciler.
+	ApplyModeServerSide ApplyMode = "ServerSide"
+)
+
+// DefaultFieldManager is the field manager used for server-side apply when none is provided.
+const DefaultFieldManager = "controller-tools"
+
+// apply applies the desired state of the provided resource using server-side apply.
+// The resource is reported as updated only if the api server changed the object,
+// which is detected by comparing the resource version before and after the apply.
+func (r *Reconciler) apply(ctx context.Context, owner client.Object, res *reconcileResource) (controllerutil.OperationResult, error) {
+	desired := res.build()
+	err := res.builder.Update(desired)
+	if err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+
+	err = r.setOwner(owner, desired)
+	if err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+
+	// Typed objects must carry their type meta to be applied.
+	desired.GetObjectKind().SetGroupVersionKind(res.gvk)
+	desired.SetManagedFields(nil)
+	desired.SetResourceVersion("")
+
+	opts := []client.PatchOption{client.FieldOwner(r.fieldManager())}
+	if r.ForceConflicts {
+		opts = append(opts, client.ForceOwnership)
+	}
+	if r.DryRun {
+		opts = append(opts, client.DryRunAll)
+	}
+
+	err = r.Client.Patch(ctx, desired, client.Apply, opts...)
+	if err != nil {
+		if res.found {
+			return controllerutil.OperationResultUpdated, err
+		}
+		return controllerutil.OperationResultCreated, err
+	}
+
+	changed := desired.GetResourceVersion() != res.current.GetResourceVersion()
+	if r.DryRun && res.found {
+		// Dry-run requests don't change the resource version, compare objects instead.
+		diff, err := r.diffObjects(res.current, desired)
+		if err != nil {
+			return controllerutil.OperationResultNone, err
+		}
+		changed = len(diff.patch) > 0
+	}
+
+	result := controllerutil.OperationResultNone
+	if !res.found {
+		result = controllerutil.OperationResultCreated
+	} else if changed {
+		result = controllerutil.OperationResultUpdated
+	}
+
+	res.current = desired
+	res.found = true
+
+	return result, nil
+}
+
+func (r *Reconciler) fieldManager() string {
+	if r.FieldManager != "" {
+		return r.FieldManager
+	}
+	return DefaultFieldManager
+}
@@ -0,0 +1,106 @@
+// Licensed to Alexandre VILAIN under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Alexandre VILAIN licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package reconciler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alexandrevilain/controller-tools/pkg/finalizer"
+	"github.com/alexandrevilain/controller-tools/pkg/resource"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// CleanupBuilders deletes resources produced by the provided builders which are not garbage collected
+// by the api server when the owner is deleted, because they don't have an owner reference to it,
+// like cluster-scoped resources of a namespaced owner. Resources tracked using labels by another owner are kept.
+// Resources are deleted dependents first. It returns true once all those resources are gone.
+func (r *Reconciler) CleanupBuilders(ctx context.Context, owner client.Object, builders []resource.Builder) (bool, error) {
+	logger := log.FromContext(ctx)
+
+	resources, err := r.getReconcileResourceFromBuilders(ctx, builders)
+	if err != nil {
+		return false, err
+	}
+
+	err = fetchErrors(resources)
+	if err != nil {
+		return false, err
+	}
+
+	resources, err = r.sortResourcesByDependencies(resources)
+	if err != nil {
+		return false, err
+	}
+
+	done := true
+	for i := len(resources) - 1; i >= 0; i-- {
+		res := resources[i]
+		if !res.found || hasOwnerReference(owner, res.current) || isTrackedByAnotherOwner(owner, res.current) {
+			continue
+		}
+
+		// The resource is still there, wait for its deletion to complete.
+		done = false
+		if !res.current.GetDeletionTimestamp().IsZero() {
+			continue
+		}
+
+		logger.Info("Deleting resource not garbage collected with its owner", "kind", res.gvk.Kind, "name", res.key.Name, "namespace", res.key.Namespace)
+		err := r.Client.Delete(ctx, res.current)
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		r.logAndRecordOperationResult(ctx, owner, res.current, OperationResultDeleted, err)
+		if err != nil {
+			return false, fmt.Errorf("can't delete %s: %w", res, err)
+		}
+	}
+
+	return done, nil
+}
+
+// CleanupHandler returns a finalizer handler running CleanupBuilders for the object being deleted,
+// with the builders returned by the provided function.
+// The handler asks to be requeued until all resources are gone.
+func (r *Reconciler) CleanupHandler(builders func(owner client.Object) []resource.Builder) finalizer.Handler {
+	return finalizer.HandlerFunc(func(ctx context.Context, owner client.Object) (finalizer.HandlerResult, error) {
+		done, err := r.CleanupBuilders(ctx, owner, builders(owner))
+		if err != nil {
+			return finalizer.HandlerResult{}, err
+		}
+
+		if !done {
+			return finalizer.HandlerResult{RequeueAfter: DefaultRequeueAfter}, nil
+		}
+
+		return finalizer.HandlerResult{}, nil
+	})
+}
+
+// hasOwnerReference returns whenever the provided object has an owner reference to owner.
+func hasOwnerReference(owner, object client.Object) bool {
+	for _, ref := range object.GetOwnerReferences() {
+		if ref.UID == owner.GetUID() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,253 @@
+// Licensed to Alexandre VILAIN under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Alexandre VILAIN licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package reconciler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/alexandrevilain/controller-tools/pkg/resource"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// DependencyCycleError is returned when builders or jobs dependencies can't be ordered because they form a cycle.
+type DependencyCycleError struct {
+	// Resources lists the resources or jobs involved in the cycle.
+	Resources []string
+}
+
+func (e *DependencyCycleError) Error() string {
+	return fmt.Sprintf("dependency cycle detected between resources: %s", strings.Join(e.Resources, ", "))
+}
+
+// dependencyKey identifies a resource by its kind, namespace and name.
+type dependencyKey struct {
+	schema.GroupKind
+	client.ObjectKey
+}
+
+func (k dependencyKey) String() string {
+	return fmt.Sprintf("%s %s", k.GroupKind.String(), k.ObjectKey.String())
+}
+
+func (res *reconcileResource) dependencyKey() dependencyKey {
+	return dependencyKey{
+		GroupKind: res.gvk.GroupKind(),
+		ObjectKey: res.key,
+	}
+}
+
+// String returns a human readable representation of the resource.
+func (res *reconcileResource) String() string {
+	return res.dependencyKey().String()
+}
+
+// getDependencies returns the dependencies of the provided resource,
+// if its builder implements the resource.Dependent interface.
+func (r *Reconciler) getDependencies(res *reconcileResource) ([]dependencyKey, error) {
+	dependent, ok := res.builder.(resource.Dependent)
+	if !ok {
+		return nil, nil
+	}
+
+	dependencies := []dependencyKey{}
+	for _, dependency := range dependent.Dependencies() {
+		gvk, err := apiutil.GVKForObject(dependency.Object, r.Scheme)
+		if err != nil {
+			return nil, fmt.Errorf("can't get dependency kind of %s: %w", res, err)
+		}
+
+		dependencies = append(dependencies, dependencyKey{
+			GroupKind: gvk.GroupKind(),
+			ObjectKey: client.ObjectKey{
+				Name:      dependency.Name,
+				Namespace: dependency.Namespace,
+			},
+		})
+	}
+
+	return dependencies, nil
+}
+
+// sortResourcesByDependencies sorts resources topologically so that every resource comes after its dependencies.
+// Resources without dependencies between them keep the order of their builders.
+// Dependencies which are not produced by any builder are ignored when sorting.
+func (r *Reconciler) sortResourcesByDependencies(resources []*reconcileResource) ([]*reconcileResource, error) {
+	index := map[dependencyKey]int{}
+	for i, res := range resources {
+		index[res.dependencyKey()] = i
+	}
+
+	// dependents[i] lists resources depending on resources[i], inDegree[i] counts resources[i] pending dependencies.
+	dependents := make([][]int, len(resources))
+	inDegree := make([]int, len(resources))
+	for i, res := range resources {
+		dependencies, err := r.getDependencies(res)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, dependency := range dependencies {
+			j, ok := index[dependency]
+			if !ok {
+				continue
+			}
+
+			dependents[j] = append(dependents[j], i)
+			inDegree[i]++
+		}
+	}
+
+	sorted := make([]*reconcileResource, 0, len(resources))
+	done := make([]bool, len(resources))
+	for len(sorted) < len(resources) {
+		// Pick the first resource, in builders order, which has no pending dependencies.
+		next := -1
+		for i := range resources {
+			if !done[i] && inDegree[i] == 0 {
+				next = i
+				break
+			}
+		}
+
+		if next == -1 {
+			cycleErr := &DependencyCycleError{}
+			for i, res := range resources {
+				if !done[i] {
+					cycleErr.Resources = append(cycleErr.Resources, res.String())
+				}
+			}
+			return nil, cycleErr
+		}
+
+		done[next] = true
+		sorted = append(sorted, resources[next])
+		for _, dependent := range dependents[next] {
+			inDegree[dependent]--
+		}
+	}
+
+	return sorted, nil
+}
+
+// reconciliationLevels groups the indexes of the provided resources, sorted by dependencies, in levels
+// reconciled one after the other. When resources are reconciled concurrently, each level contains resources
+// whose dependencies are all in previous levels. Otherwise, each level contains a single resource.
+func (r *Reconciler) reconciliationLevels(resources []*reconcileResource) ([][]int, error) {
+	levels := [][]int{}
+	if r.concurrency() == 1 {
+		for i := range resources {
+			levels = append(levels, []int{i})
+		}
+		return levels, nil
+	}
+
+	index := map[dependencyKey]int{}
+	for i, res := range resources {
+		index[res.dependencyKey()] = i
+	}
+
+	resourceLevels := make([]int, len(resources))
+	for i, res := range resources {
+		dependencies, err := r.getDependencies(res)
+		if err != nil {
+			return nil, err
+		}
+
+		// Resources are sorted, so dependencies have a lower index.
+		for _, dependency := range dependencies {
+			if j, ok := index[dependency]; ok && resourceLevels[j]+1 > resourceLevels[i] {
+				resourceLevels[i] = resourceLevels[j] + 1
+			}
+		}
+
+		if resourceLevels[i] == len(levels) {
+			levels = append(levels, []int{})
+		}
+		levels[resourceLevels[i]] = append(levels[resourceLevels[i]], i)
+	}
+
+	return levels, nil
+}
+
+// areDependenciesReady returns whenever all dependencies of the provided resource are ready.
+// Dependencies produced by builders are checked using their reconciled state,
+// others are fetched from the api server.
+func (r *Reconciler) areDependenciesReady(ctx context.Context, res *reconcileResource, resources []*reconcileResource) (bool, error) {
+	dependent, ok := res.builder.(resource.Dependent)
+	if !ok {
+		return true, nil
+	}
+
+	reconciled := map[dependencyKey]*reconcileResource{}
+	for _, other := range resources {
+		reconciled[other.dependencyKey()] = other
+	}
+
+	for _, dependency := range dependent.Dependencies() {
+		gvk, err := apiutil.GVKForObject(dependency.Object, r.Scheme)
+		if err != nil {
+			return false, fmt.Errorf("can't get dependency kind of %s: %w", res, err)
+		}
+
+		key := dependencyKey{
+			GroupKind: gvk.GroupKind(),
+			ObjectKey: client.ObjectKey{Name: dependency.Name, Namespace: dependency.Namespace},
+		}
+
+		var object client.Object
+		if other, ok := reconciled[key]; ok {
+			if !other.found || !other.builder.Enabled() {
+				return false, nil
+			}
+			object = other.current
+		} else {
+			object = dependency.Object.DeepCopyObject().(client.Object)
+			err := r.Client.Get(ctx, key.ObjectKey, object)
+			if err != nil {
+				if apierrors.IsNotFound(err) {
+					return false, nil
+				}
+				return false, fmt.Errorf("can't get dependency %s: %w", key, err)
+			}
+		}
+
+		status, err := r.getStatus(object, gvk)
+		if err != nil {
+			return false, fmt.Errorf("can't get dependency %s status: %w", key, err)
+		}
+
+		if !status.Ready {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// getStatus returns the status of the provided object.
+// Objects returned by the client don't carry their type meta, which is required to compute their status.
+func (r *Reconciler) getStatus(object client.Object, gvk schema.GroupVersionKind) (*resource.Status, error) {
+	object = object.DeepCopyObject().(client.Object)
+	object.GetObjectKind().SetGroupVersionKind(gvk)
+	return resource.GetStatus(object)
+}
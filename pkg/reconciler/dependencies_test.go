@@ -0,0 +1,152 @@
+// Licensed to Alexandre VILAIN under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Alexandre VILAIN licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package reconciler
+
+import (
+	"testing"
+
+	"github.com/alexandrevilain/controller-tools/pkg/fake"
+	"github.com/alexandrevilain/controller-tools/pkg/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func newDependentResource(name string, dependencies ...string) *reconcileResource {
+	builder := fake.NewDeploymentBuilder(name, "default")
+	for _, dependency := range dependencies {
+		builder.DependsOn = append(builder.DependsOn, resource.Dependency{
+			Object:    &appsv1.Deployment{},
+			Name:      dependency,
+			Namespace: "default",
+		})
+	}
+
+	return &reconcileResource{
+		builder: builder,
+		gvk:     appsv1.SchemeGroupVersion.WithKind("Deployment"),
+		key:     client.ObjectKey{Name: name, Namespace: "default"},
+	}
+}
+
+func TestSortResourcesByDependencies(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(appsv1.AddToScheme(scheme))
+	utilruntime.Must(corev1.AddToScheme(scheme))
+
+	r := &Reconciler{Scheme: scheme}
+
+	tests := map[string]struct {
+		resources     []*reconcileResource
+		expectedOrder []string
+		expectedErr   string
+	}{
+		"no dependencies keeps builders order": {
+			resources: []*reconcileResource{
+				newDependentResource("a"),
+				newDependentResource("b"),
+				newDependentResource("c"),
+			},
+			expectedOrder: []string{"a", "b", "c"},
+		},
+		"dependencies come first": {
+			resources: []*reconcileResource{
+				newDependentResource("a", "c"),
+				newDependentResource("b"),
+				newDependentResource("c", "b"),
+			},
+			expectedOrder: []string{"b", "c", "a"},
+		},
+		"unknown dependencies are ignored": {
+			resources: []*reconcileResource{
+				newDependentResource("a", "external"),
+				newDependentResource("b"),
+			},
+			expectedOrder: []string{"a", "b"},
+		},
+		"cycle": {
+			resources: []*reconcileResource{
+				newDependentResource("a"),
+				newDependentResource("b", "c"),
+				newDependentResource("c", "b"),
+			},
+			expectedErr: "dependency cycle detected between resources: Deployment.apps default/b, Deployment.apps default/c",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(tt *testing.T) {
+			sorted, err := r.sortResourcesByDependencies(test.resources)
+			if test.expectedErr != "" {
+				var cycleErr *DependencyCycleError
+				assert.ErrorAs(tt, err, &cycleErr)
+				assert.EqualError(tt, err, test.expectedErr)
+				return
+			}
+
+			require.NoError(tt, err)
+
+			order := []string{}
+			for _, res := range sorted {
+				order = append(order, res.key.Name)
+			}
+			assert.Equal(tt, test.expectedOrder, order)
+		})
+	}
+}
+
+func TestReconciliationLevels(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(appsv1.AddToScheme(scheme))
+
+	resources := []*reconcileResource{
+		newDependentResource("a"),
+		newDependentResource("b", "a"),
+		newDependentResource("c"),
+		newDependentResource("d", "b", "c"),
+		newDependentResource("e", "external"),
+	}
+
+	tests := map[string]struct {
+		maxConcurrency int
+		expectedLevels [][]int
+	}{
+		"sequential": {
+			maxConcurrency: 0,
+			expectedLevels: [][]int{{0}, {1}, {2}, {3}, {4}},
+		},
+		"concurrent": {
+			maxConcurrency: 4,
+			expectedLevels: [][]int{{0, 2, 4}, {1}, {3}},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(tt *testing.T) {
+			r := &Reconciler{Scheme: scheme, MaxConcurrency: test.maxConcurrency}
+
+			levels, err := r.reconciliationLevels(resources)
+			require.NoError(tt, err)
+			assert.Equal(tt, test.expectedLevels, levels)
+		})
+	}
+}
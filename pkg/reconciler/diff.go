@@ -0,0 +1,139 @@
+// Licensed to Alexandre VILAIN under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Alexandre VILAIN licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package reconciler
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"gomodules.xyz/jsonpatch/v2"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+// objectDiff is the difference between two states of an object.
+type objectDiff struct {
+	// diff is an unified diff of the YAML representations of the object.
+	diff string
+	// patch is the JSON patch turning the first state into the second one.
+	patch []jsonpatch.Operation
+}
+
+// diffObjects returns the difference between the before and after states of an object.
+// A nil state means that the object doesn't exist. Fields managed by the api server
+// which change on every write, like managed fields and resource version, are ignored.
+func (r *Reconciler) diffObjects(before, after client.Object) (*objectDiff, error) {
+	beforeContent, err := diffableContent(before)
+	if err != nil {
+		return nil, err
+	}
+
+	afterContent, err := diffableContent(after)
+	if err != nil {
+		return nil, err
+	}
+
+	beforeJSON, err := json.Marshal(beforeContent)
+	if err != nil {
+		return nil, err
+	}
+
+	afterJSON, err := json.Marshal(afterContent)
+	if err != nil {
+		return nil, err
+	}
+
+	patch, err := jsonpatch.CreatePatch(beforeJSON, afterJSON)
+	if err != nil {
+		return nil, fmt.Errorf("can't compute JSON patch: %w", err)
+	}
+
+	if len(patch) == 0 {
+		return &objectDiff{}, nil
+	}
+
+	beforeYAML, err := toYAML(beforeContent)
+	if err != nil {
+		return nil, err
+	}
+
+	afterYAML, err := toYAML(afterContent)
+	if err != nil {
+		return nil, err
+	}
+
+	object := after
+	if object == nil {
+		object = before
+	}
+	name := r.describeObject(object)
+
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(beforeYAML),
+		B:        difflib.SplitLines(afterYAML),
+		FromFile: "live/" + name,
+		ToFile:   "desired/" + name,
+		Context:  3,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can't compute diff: %w", err)
+	}
+
+	return &objectDiff{
+		diff:  diff,
+		patch: patch,
+	}, nil
+}
+
+// diffableContent returns the unstructured content of the provided object, without fields changing on every write.
+// The type meta is removed as well, since typed objects returned by the client don't always carry it.
+func diffableContent(obj client.Object) (map[string]interface{}, error) {
+	if obj == nil {
+		return map[string]interface{}{}, nil
+	}
+
+	// Unstructured objects return their own content, work on a copy to not alter them.
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj.DeepCopyObject())
+	if err != nil {
+		return nil, err
+	}
+
+	unstructured.RemoveNestedField(content, "apiVersion")
+	unstructured.RemoveNestedField(content, "kind")
+	unstructured.RemoveNestedField(content, "metadata", "managedFields")
+	unstructured.RemoveNestedField(content, "metadata", "resourceVersion")
+
+	return content, nil
+}
+
+// toYAML returns the YAML representation of the provided content, or an empty string for empty content.
+func toYAML(content map[string]interface{}) (string, error) {
+	if len(content) == 0 {
+		return "", nil
+	}
+
+	data, err := yaml.Marshal(content)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
@@ -0,0 +1,90 @@
+// Licensed to Alexandre VILAIN under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Alexandre VILAIN licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package reconciler
+
+import (
+	"errors"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// ErrorPolicy defines how ReconcileBuilders handles errors.
+type ErrorPolicy string
+
+const (
+	// ErrorPolicyFailFast stops the reconciliation on the first error.
+	ErrorPolicyFailFast ErrorPolicy = "FailFast"
+	// ErrorPolicyBestEffort reconciles all resources whatever errors occur, then returns
+	// the result along with a ReconcileError aggregating all errors.
+	ErrorPolicyBestEffort ErrorPolicy = "BestEffort"
+)
+
+// ObjectError is an error which occurred while reconciling an object.
+type ObjectError struct {
+	// GVK is the object's GroupVersionKind.
+	GVK schema.GroupVersionKind
+	// Key is the object's namespace and name.
+	Key client.ObjectKey
+	// OperationResult is the operation which was attempted on the object, if any.
+	OperationResult controllerutil.OperationResult
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *ObjectError) Error() string {
+	if e.Key.Namespace == "" {
+		return fmt.Sprintf("%s %s: %v", e.GVK.Kind, e.Key.Name, e.Err)
+	}
+	return fmt.Sprintf("%s %s: %v", e.GVK.Kind, e.Key, e.Err)
+}
+
+func (e *ObjectError) Unwrap() error {
+	return e.Err
+}
+
+// ReconcileError aggregates errors which occurred while reconciling builders using ErrorPolicyBestEffort.
+// Errors related to a single object are ObjectErrors.
+type ReconcileError struct {
+	// Errors lists errors in reconciliation order.
+	Errors []error
+}
+
+func (e *ReconcileError) Error() string {
+	return kerrors.NewAggregate(e.Errors).Error()
+}
+
+// Unwrap returns the aggregated errors, so that they can be inspected using errors.Is and errors.As.
+func (e *ReconcileError) Unwrap() []error {
+	return e.Errors
+}
+
+// ObjectErrors returns the aggregated errors related to a single object.
+func (e *ReconcileError) ObjectErrors() []*ObjectError {
+	result := []*ObjectError{}
+	for _, err := range e.Errors {
+		var objectErr *ObjectError
+		if errors.As(err, &objectErr) {
+			result = append(result, objectErr)
+		}
+	}
+	return result
+}
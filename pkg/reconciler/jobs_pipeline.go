@@ -0,0 +1,174 @@
+// Licensed to Alexandre VILAIN under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Alexandre VILAIN licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package reconciler
+
+import (
+	"fmt"
+	"time"
+)
+
+// JobPhase is the phase of a job reconciled by the JosbReconciler.
+type JobPhase string
+
+const (
+	// JobPhasePending means that the job is waiting for its dependencies or for a slot to run.
+	JobPhasePending JobPhase = "Pending"
+	// JobPhaseRunning means that the job is running or waiting to be retried.
+	JobPhaseRunning JobPhase = "Running"
+	// JobPhaseSucceeded means that the job completed.
+	JobPhaseSucceeded JobPhase = "Succeeded"
+	// JobPhaseFailed means that the job failed and won't be retried anymore.
+	JobPhaseFailed JobPhase = "Failed"
+	// JobPhaseSkipped means that the job was skipped by its Skip function.
+	JobPhaseSkipped JobPhase = "Skipped"
+)
+
+// JobsProgress summarizes the phases of the reconciled jobs.
+// It can be embedded in custom resources status.
+type JobsProgress struct {
+	Total     int `json:"total"`
+	Pending   int `json:"pending"`
+	Running   int `json:"running"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+	Skipped   int `json:"skipped"`
+}
+
+func (p JobsProgress) String() string {
+	return fmt.Sprintf("%d/%d jobs done: %d succeeded, %d skipped, %d running, %d pending, %d failed",
+		p.Succeeded+p.Skipped, p.Total, p.Succeeded, p.Skipped, p.Running, p.Pending, p.Failed)
+}
+
+// add counts a job in the provided phase.
+func (p *JobsProgress) add(phase JobPhase) {
+	p.Total++
+	switch phase {
+	case JobPhasePending:
+		p.Pending++
+	case JobPhaseRunning:
+		p.Running++
+	case JobPhaseSucceeded:
+		p.Succeeded++
+	case JobPhaseFailed:
+		p.Failed++
+	case JobPhaseSkipped:
+		p.Skipped++
+	}
+}
+
+// JobsResult is the result of the reconciliation of jobs.
+type JobsResult struct {
+	// Progress summarizes the phases of the jobs.
+	Progress JobsProgress
+	// Jobs contains the status of every job, in execution order.
+	Jobs []JobStatus
+	// RequeueAfter is the suggested duration after which the owner should be reconciled again.
+	// It's zero when no job is pending nor running.
+	RequeueAfter time.Duration
+}
+
+// Done returns true when all jobs succeeded or were skipped.
+func (r *JobsResult) Done() bool {
+	return r.Progress.Succeeded+r.Progress.Skipped == r.Progress.Total
+}
+
+// requeue suggests to reconcile the owner again after the provided duration, unless it's already suggested sooner.
+func (r *JobsResult) requeue(after time.Duration) {
+	if after > 0 && (r.RequeueAfter == 0 || after < r.RequeueAfter) {
+		r.RequeueAfter = after
+	}
+}
+
+// jobsDependencies returns the names of the jobs every job depends on.
+// Jobs depend on the previous one, unless a job declares its dependencies.
+func jobsDependencies(jobs []*Job) (map[string][]string, error) {
+	sequential := true
+	names := map[string]bool{}
+	for _, job := range jobs {
+		if names[job.Name] {
+			return nil, fmt.Errorf("job %s is declared twice", job.Name)
+		}
+		names[job.Name] = true
+		if len(job.DependsOn) > 0 {
+			sequential = false
+		}
+	}
+
+	dependencies := map[string][]string{}
+	for i, job := range jobs {
+		if sequential {
+			if i > 0 {
+				dependencies[job.Name] = []string{jobs[i-1].Name}
+			}
+			continue
+		}
+
+		for _, dependency := range job.DependsOn {
+			if !names[dependency] {
+				return nil, fmt.Errorf("job %s depends on unknown job %s", job.Name, dependency)
+			}
+		}
+		dependencies[job.Name] = job.DependsOn
+	}
+
+	return dependencies, nil
+}
+
+// sortJobsByDependencies returns the provided jobs ordered after their dependencies.
+// Independent jobs keep their relative order.
+func sortJobsByDependencies(jobs []*Job, dependencies map[string][]string) ([]*Job, error) {
+	sorted := make([]*Job, 0, len(jobs))
+	done := map[string]bool{}
+
+	for len(sorted) < len(jobs) {
+		next := -1
+		for i, job := range jobs {
+			if done[job.Name] {
+				continue
+			}
+
+			ready := true
+			for _, dependency := range dependencies[job.Name] {
+				if !done[dependency] {
+					ready = false
+					break
+				}
+			}
+
+			if ready {
+				next = i
+				break
+			}
+		}
+
+		if next == -1 {
+			cycleErr := &DependencyCycleError{}
+			for _, job := range jobs {
+				if !done[job.Name] {
+					cycleErr.Resources = append(cycleErr.Resources, job.Name)
+				}
+			}
+			return nil, cycleErr
+		}
+
+		done[jobs[next].Name] = true
+		sorted = append(sorted, jobs[next])
+	}
+
+	return sorted, nil
+}
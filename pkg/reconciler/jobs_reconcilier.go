@@ -19,25 +19,133 @@ package reconciler
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
+	"strconv"
 	"time"
 
+	"github.com/alexandrevilain/controller-tools/pkg/hash"
 	"github.com/alexandrevilain/controller-tools/pkg/resource"
 	batchv1 "k8s.io/api/batch/v1"
-	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/types"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
+const (
+	// JobAttemptAnnotation is the annotation holding the attempt number of Jobs created by the JosbReconciler.
+	JobAttemptAnnotation = "controller-tools.alexandrevilain.dev/job-attempt"
+	// JobSpecHashAnnotation is the annotation holding the hash of the desired spec of Jobs created by the JosbReconciler.
+	JobSpecHashAnnotation = "controller-tools.alexandrevilain.dev/job-spec-hash"
+	// JobRunAnnotation is the annotation holding the run number of Jobs created by the JosbReconciler.
+	// Every retry or recreation of a Job is a new run.
+	JobRunAnnotation = "controller-tools.alexandrevilain.dev/job-run"
+	// JobFailureMessageAnnotation is the annotation caching the message explaining why a Job created by the JosbReconciler failed.
+	JobFailureMessageAnnotation = "controller-tools.alexandrevilain.dev/job-failure-message"
+	// JobFailureReportedAnnotation is set on Jobs created by the JosbReconciler once their final failure was reported.
+	JobFailureReportedAnnotation = "controller-tools.alexandrevilain.dev/job-failure-reported"
+	// JobNameLabel is set on Jobs created by the JosbReconciler, its value is the name of their reconciler.Job.
+	JobNameLabel = "controller-tools.alexandrevilain.dev/job-name"
+	// JobOwnerUIDLabel is set on Jobs created by the JosbReconciler, its value is the owner's UID.
+	JobOwnerUIDLabel = "controller-tools.alexandrevilain.dev/job-owner-uid"
+
+	// DefaultJobRetryBackoff is the delay before the first retry of a failed Job, when none is provided.
+	DefaultJobRetryBackoff = 10 * time.Second
+	// DefaultJobRetryMaxBackoff is the maximum delay before retrying a failed Job, when none is provided.
+	DefaultJobRetryMaxBackoff = 5 * time.Minute
+)
+
 type Job struct {
-	Name          string
-	Command       []string
+	// Name identifies the Job, it must be a valid label value.
+	Name    string
+	Command []string
+	// DependsOn lists the names of the jobs which must succeed before running this one.
+	// When no job of a pipeline declares dependencies, every job depends on the previous one.
+	DependsOn     []string
 	Skip          func(owner runtime.Object) bool
 	ReportSuccess func(owner runtime.Object) error
+	// ReportFailure is called once when the Job failed and won't be retried anymore.
+	// It's optional.
+	ReportFailure func(owner runtime.Object, err *JobFailedError) error
+	// RetryPolicy overrides the reconciler's retry policy for this Job.
+	RetryPolicy *JobRetryPolicy
+}
+
+// JobRetryPolicy defines how failed Jobs are retried.
+// Jobs are retried by creating a new run, once their pod's backoff limit is reached.
+type JobRetryPolicy struct {
+	// MaxRetries is the number of times a failed Job is recreated.
+	// Zero means failed Jobs aren't retried.
+	MaxRetries int
+	// Backoff is the delay between the Job failure and its first retry, doubled on every retry.
+	// Defaults to DefaultJobRetryBackoff.
+	Backoff time.Duration
+	// MaxBackoff is the maximum delay between a Job failure and its retry.
+	// Defaults to DefaultJobRetryMaxBackoff.
+	MaxBackoff time.Duration
+}
+
+// delay returns the delay between the failure of the provided attempt and the next one.
+func (p JobRetryPolicy) delay(attempt int) time.Duration {
+	backoff := p.Backoff
+	if backoff <= 0 {
+		backoff = DefaultJobRetryBackoff
+	}
+	maxBackoff := p.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultJobRetryMaxBackoff
+	}
+
+	delay := float64(backoff) * math.Pow(2, float64(attempt-1))
+	if delay > float64(maxBackoff) {
+		return maxBackoff
+	}
+	return time.Duration(delay)
+}
+
+// JobFailedError is returned when a Job failed and won't be retried anymore.
+type JobFailedError struct {
+	// Name is the name of the failed reconciler.Job.
+	Name string
+	// Key is the namespace and name of the failed Job.
+	Key client.ObjectKey
+	// Attempts is the number of times the Job was run.
+	Attempts int
+	// Reason is the reason of the Job's Failed condition.
+	Reason string
+	// Message is the message of the Job's Failed condition.
+	Message string
+}
+
+func (e *JobFailedError) Error() string {
+	return fmt.Sprintf("job %s failed after %d attempt(s): %s: %s", e.Name, e.Attempts, e.Reason, e.Message)
+}
+
+// JobsFailedError aggregates the errors of the Jobs which failed and won't be retried anymore.
+type JobsFailedError struct {
+	// Errors lists the errors of failed Jobs, in execution order.
+	Errors []*JobFailedError
+}
+
+func (e *JobsFailedError) Error() string {
+	errs := make([]error, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		errs = append(errs, err)
+	}
+	return kerrors.NewAggregate(errs).Error()
+}
+
+// Unwrap returns the aggregated errors, so that they can be inspected using errors.Is and errors.As.
+func (e *JobsFailedError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		errs = append(errs, err)
+	}
+	return errs
 }
 
 type JobBuilderFactory func(owner runtime.Object, scheme *runtime.Scheme, name string, command []string) resource.Builder
@@ -46,51 +154,429 @@ type JosbReconciler struct {
 	client.Client
 	Scheme   *runtime.Scheme
 	Recorder record.EventRecorder
+	// RetryPolicy defines how failed Jobs are retried, unless overridden by the Job.
+	// Failed Jobs aren't retried by default.
+	RetryPolicy JobRetryPolicy
+	// WaitForPreviousRun delays the recreation of Jobs whose spec changed until their previous run finished.
+	// By default, they're recreated right away, even if they're still running.
+	WaitForPreviousRun bool
+	// RetentionPolicy defines how finished Jobs are cleaned up.
+	// By default, only the latest run of every Job is kept.
+	RetentionPolicy JobRetentionPolicy
+	// MaxParallelism is the maximum number of Jobs running at once.
+	// Zero means no limit.
+	MaxParallelism int
+	// PodLogs gets the logs of failed Jobs' pods whose container didn't write a termination message.
+	// It's only called once per failed Job, as failure messages are cached in the Job's annotations.
+	// It's optional, see TailPodLogs.
+	PodLogs PodLogsFunc
 }
 
-func (r *JosbReconciler) Reconcile(ctx context.Context, owner client.Object, builderFactory JobBuilderFactory, jobs []*Job) (time.Duration, error) {
+// Reconcile runs the provided jobs in order, waiting for each of them to complete before running the next one.
+// When a job declares its dependencies, jobs are instead run as soon as their dependencies succeeded,
+// jobs without dependencies being run right away, up to MaxParallelism jobs at once.
+//
+// It returns the status of every job and their progress, along with the duration after which the owner should be reconciled again
+// when a Job is still running or waiting for a retry.
+// A JobsFailedError is returned when Jobs failed and won't be retried anymore,
+// it wraps a JobFailedError for every failed Job.
+// As Job pod templates are immutable, Jobs whose desired spec changed are recreated.
+//
+// Every retry or recreation of a Job is a new run: the first run is named after the builder's Job,
+// next ones are suffixed with their run number. Previous runs are cleaned up according to the retention policy.
+func (r *JosbReconciler) Reconcile(ctx context.Context, owner client.Object, builderFactory JobBuilderFactory, jobs []*Job) (*JobsResult, error) {
 	logger := log.FromContext(ctx)
 
+	dependencies, err := jobsDependencies(jobs)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs, err = sortJobsByDependencies(jobs, dependencies)
+	if err != nil {
+		return nil, err
+	}
+
+	states := make([]*jobState, 0, len(jobs))
+	running := 0
 	for _, job := range jobs {
-		if job.Skip(owner) {
-			continue
+		state, err := r.getJobState(ctx, owner, builderFactory, job)
+		if err != nil {
+			return nil, err
 		}
+		states = append(states, state)
+
+		if len(state.runs) > 0 && !isJobFinished(state.runs[0]) {
+			running++
+		}
+	}
+
+	pipeline := &jobPipeline{
+		maxParallelism: r.MaxParallelism,
+		running:        running,
+	}
+
+	result := &JobsResult{}
+	phases := map[string]JobPhase{}
+	failures := []*JobFailedError{}
+	for _, state := range states {
+		phase, requeueAfter, err := r.reconcileJobState(ctx, owner, state, phases, dependencies, pipeline)
+		var failedErr *JobFailedError
+		if err != nil && !errors.As(err, &failedErr) {
+			return nil, err
+		}
+		if err != nil {
+			failures = append(failures, failedErr)
+		}
+
+		phases[state.job.Name] = phase
+		result.Jobs = append(result.Jobs, r.jobStatus(ctx, state, phase))
+		result.Progress.add(phase)
+		result.requeue(requeueAfter)
+	}
+
+	logger.Info("Reconciled jobs", "progress", result.Progress.String())
+
+	if len(failures) > 0 {
+		return result, &JobsFailedError{Errors: failures}
+	}
+
+	return result, nil
+}
+
+// jobState is the state of a job, fetched before reconciling jobs.
+type jobState struct {
+	job     *Job
+	skipped bool
+	// expectedJob is the Job produced by the job's builder.
+	expectedJob *batchv1.Job
+	// runs are the runs of the job, latest first.
+	runs []*batchv1.Job
+}
 
-		logger.Info("Checking for job", "name", job.Name)
+// getJobState returns the state of the provided job.
+// Runs of skipped jobs are cleaned up according to the retention policy.
+func (r *JosbReconciler) getJobState(ctx context.Context, owner client.Object, builderFactory JobBuilderFactory, job *Job) (*jobState, error) {
+	jobBuilder := builderFactory(owner, r.Scheme, job.Name, job.Command)
+
+	expectedJob, err := r.desiredJob(owner, job, jobBuilder)
+	if err != nil {
+		return nil, err
+	}
 
-		jobBuilder := builderFactory(owner, r.Scheme, job.Name, job.Command)
+	runs, err := r.listJobRuns(ctx, owner, job, expectedJob)
+	if err != nil {
+		return nil, err
+	}
 
-		expectedJob := jobBuilder.Build()
+	state := &jobState{
+		job:         job,
+		skipped:     job.Skip(owner),
+		expectedJob: expectedJob,
+		runs:        runs,
+	}
 
-		matchingJob := &batchv1.Job{}
-		err := r.Client.Get(ctx, types.NamespacedName{Name: expectedJob.GetName(), Namespace: expectedJob.GetNamespace()}, matchingJob)
+	if state.skipped {
+		err := r.cleanupSucceededJob(ctx, runs)
 		if err != nil {
-			if apierrors.IsNotFound(err) {
-				// The job is not found, create it
-				_, err := controllerutil.CreateOrUpdate(ctx, r.Client, expectedJob, func() error {
-					return jobBuilder.Update(expectedJob)
-				})
-				if err != nil {
-					return 0, err
-				}
-			} else {
-				return 0, fmt.Errorf("can't get job: %w", err)
-			}
+			return nil, err
 		}
+	}
+
+	return state, nil
+}
+
+// jobPipeline limits the number of Jobs running at once.
+type jobPipeline struct {
+	maxParallelism int
+	running        int
+}
+
+// start returns true when a new Job can be started, and counts it as running.
+func (p *jobPipeline) start() bool {
+	if p.maxParallelism > 0 && p.running >= p.maxParallelism {
+		return false
+	}
+	p.running++
+	return true
+}
 
-		if matchingJob.Status.Succeeded != 1 {
-			logger.Info("Waiting for job to complete", "name", job.Name)
+// reconcileJobState runs the provided job if its dependencies succeeded, and returns its phase.
+func (r *JosbReconciler) reconcileJobState(ctx context.Context, owner client.Object, state *jobState, phases map[string]JobPhase, dependencies map[string][]string, pipeline *jobPipeline) (JobPhase, time.Duration, error) {
+	logger := log.FromContext(ctx)
+	job := state.job
+
+	if state.skipped {
+		return JobPhaseSkipped, 0, nil
+	}
 
-			// Requeue after 10 seconds
-			return 10 * time.Second, nil
+	for _, dependency := range dependencies[job.Name] {
+		if phase := phases[dependency]; phase != JobPhaseSucceeded && phase != JobPhaseSkipped {
+			logger.V(1).Info("Waiting for job dependency", "name", job.Name, "dependency", dependency)
+			return JobPhasePending, 0, nil
 		}
+	}
+
+	logger.Info("Checking for job", "name", job.Name)
 
-		logger.Info("Job is finished", "name", job.Name)
+	runs := state.runs
+	if len(runs) == 0 {
+		if !pipeline.start() {
+			logger.Info("Waiting for running jobs before running job", "name", job.Name)
+			return JobPhasePending, DefaultRequeueAfter, nil
+		}
 
-		err = job.ReportSuccess(owner)
+		// The job is not found, create it
+		err := r.createJobRun(ctx, state, 1, 1)
 		if err != nil {
-			return 0, fmt.Errorf("can't report job success: %w", err)
+			return "", 0, err
+		}
+
+		logger.Info("Waiting for job to complete", "name", job.Name)
+		return JobPhaseRunning, DefaultRequeueAfter, nil
+	}
+
+	err := r.pruneJobRuns(ctx, runs[1:])
+	if err != nil {
+		return "", 0, err
+	}
+
+	matchingJob := runs[0]
+
+	if hasJobSpecChanged(matchingJob, state.expectedJob) {
+		return r.recreateChangedJob(ctx, owner, state, pipeline)
+	}
+
+	if failed := getJobCondition(matchingJob, batchv1.JobFailed); failed != nil {
+		return r.handleJobFailure(ctx, owner, state, failed, pipeline)
+	}
+
+	if getJobCondition(matchingJob, batchv1.JobComplete) == nil {
+		logger.Info("Waiting for job to complete", "name", job.Name)
+		return JobPhaseRunning, DefaultRequeueAfter, nil
+	}
+
+	logger.Info("Job is finished", "name", job.Name)
+
+	err = job.ReportSuccess(owner)
+	if err != nil {
+		return "", 0, fmt.Errorf("can't report job success: %w", err)
+	}
+
+	return JobPhaseSucceeded, 0, nil
+}
+
+// handleJobFailure retries the latest run of the provided Job, which failed, according to the retry policy,
+// or reports its failure once it ran out of retries.
+func (r *JosbReconciler) handleJobFailure(ctx context.Context, owner client.Object, state *jobState, failed *batchv1.JobCondition, pipeline *jobPipeline) (JobPhase, time.Duration, error) {
+	logger := log.FromContext(ctx)
+
+	job := state.job
+	failedJob := state.runs[0]
+	attempt := getJobAttempt(failedJob)
+	policy := r.RetryPolicy
+	if job.RetryPolicy != nil {
+		policy = *job.RetryPolicy
+	}
+
+	if attempt <= policy.MaxRetries {
+		delay := policy.delay(attempt) - time.Since(failed.LastTransitionTime.Time)
+		if delay > 0 {
+			logger.Info("Waiting before retrying failed job", "name", job.Name, "attempt", attempt, "delay", delay)
+			return JobPhaseRunning, delay, nil
+		}
+
+		if !pipeline.start() {
+			logger.Info("Waiting for running jobs before retrying job", "name", job.Name)
+			return JobPhaseRunning, DefaultRequeueAfter, nil
+		}
+
+		logger.Info("Retrying failed job", "name", job.Name, "attempt", attempt+1)
+
+		err := r.replaceJob(ctx, state, attempt+1)
+		if err != nil {
+			return "", 0, err
+		}
+
+		r.recordEvent(owner, corev1.EventTypeNormal, "JobRetried", fmt.Sprintf("job %s failed, retrying (attempt %d)", job.Name, attempt+1))
+		return JobPhaseRunning, DefaultRequeueAfter, nil
+	}
+
+	failedErr := &JobFailedError{
+		Name:     job.Name,
+		Key:      client.ObjectKeyFromObject(failedJob),
+		Attempts: attempt,
+		Reason:   failed.Reason,
+		Message:  failed.Message,
+	}
+
+	// The failure is reported once per run, following reconciliations only return it.
+	if _, ok := failedJob.Annotations[JobFailureReportedAnnotation]; ok {
+		return JobPhaseFailed, 0, failedErr
+	}
+
+	logger.Info("Job failed", "name", job.Name, "attempts", attempt, "reason", failed.Reason)
+	r.recordEvent(owner, corev1.EventTypeWarning, "JobFailed", failedErr.Error())
+
+	if job.ReportFailure != nil {
+		err := job.ReportFailure(owner, failedErr)
+		if err != nil {
+			return "", 0, fmt.Errorf("can't report job failure: %w", err)
+		}
+	}
+
+	patch := client.MergeFrom(failedJob.DeepCopy())
+	setJobAnnotation(failedJob, JobFailureReportedAnnotation, "true")
+	err := r.Client.Patch(ctx, failedJob, patch)
+	if err != nil {
+		return "", 0, fmt.Errorf("can't record job failure report: %w", err)
+	}
+
+	return JobPhaseFailed, 0, failedErr
+}
+
+// recreateChangedJob recreates the provided Job whose desired spec changed,
+// once its latest run finished when WaitForPreviousRun is enabled.
+// Running Jobs are replaced right away, finished ones wait for a slot to run.
+func (r *JosbReconciler) recreateChangedJob(ctx context.Context, owner client.Object, state *jobState, pipeline *jobPipeline) (JobPhase, time.Duration, error) {
+	logger := log.FromContext(ctx)
+
+	job := state.job
+	finished := isJobFinished(state.runs[0])
+	if r.WaitForPreviousRun && !finished {
+		logger.Info("Waiting for previous job run to finish before recreating it", "name", job.Name)
+		return JobPhaseRunning, DefaultRequeueAfter, nil
+	}
+
+	if finished && !pipeline.start() {
+		logger.Info("Waiting for running jobs before recreating job", "name", job.Name)
+		return JobPhasePending, DefaultRequeueAfter, nil
+	}
+
+	logger.Info("Recreating job as its spec changed", "name", job.Name)
+
+	err := r.replaceJob(ctx, state, 1)
+	if err != nil {
+		return "", 0, err
+	}
+
+	r.recordEvent(owner, corev1.EventTypeNormal, "JobRecreated", fmt.Sprintf("job %s spec changed, recreating it", job.Name))
+	return JobPhaseRunning, DefaultRequeueAfter, nil
+}
+
+// desiredJob returns the Job produced by the provided builder, labeled with its job name and owner
+// and annotated with the hash of its spec.
+func (r *JosbReconciler) desiredJob(owner client.Object, job *Job, jobBuilder resource.Builder) (*batchv1.Job, error) {
+	object := jobBuilder.Build()
+	err := jobBuilder.Update(object)
+	if err != nil {
+		return nil, err
+	}
+
+	desired, err := resource.Cast[*batchv1.Job](object)
+	if err != nil {
+		return nil, err
+	}
+
+	specHash, err := hash.Sha256(desired.Spec)
+	if err != nil {
+		return nil, fmt.Errorf("can't compute job spec hash: %w", err)
+	}
+	setJobAnnotation(desired, JobSpecHashAnnotation, specHash)
+
+	if desired.Labels == nil {
+		desired.Labels = map[string]string{}
+	}
+	desired.Labels[JobNameLabel] = job.Name
+	desired.Labels[JobOwnerUIDLabel] = string(owner.GetUID())
+
+	return desired, nil
+}
+
+// replaceJob creates a new run of a job with the provided attempt number.
+// Its previous runs, latest first, are then kept or deleted according to the retention policy.
+func (r *JosbReconciler) replaceJob(ctx context.Context, state *jobState, attempt int) error {
+	previousRuns := state.runs
+	err := r.createJobRun(ctx, state, getJobRun(previousRuns[0])+1, attempt)
+	if err != nil {
+		return err
+	}
+
+	return r.pruneJobRuns(ctx, previousRuns)
+}
+
+// createJobRun creates the expected Job of the provided job, annotated with its run and attempt numbers,
+// and adds it to the job's runs.
+// Runs after the first one are suffixed with their run number.
+func (r *JosbReconciler) createJobRun(ctx context.Context, state *jobState, run, attempt int) error {
+	job := state.expectedJob
+	if run > 1 {
+		job.Name = fmt.Sprintf("%s-%d", job.Name, run)
+	}
+	setJobAnnotation(job, JobRunAnnotation, strconv.Itoa(run))
+	setJobAnnotation(job, JobAttemptAnnotation, strconv.Itoa(attempt))
+
+	err := r.Client.Create(ctx, job)
+	if err != nil {
+		return fmt.Errorf("can't create job: %w", err)
+	}
+
+	state.runs = append([]*batchv1.Job{job}, state.runs...)
+	return nil
+}
+
+func (r *JosbReconciler) recordEvent(owner client.Object, eventtype, reason, message string) {
+	if r.Recorder != nil {
+		r.Recorder.Event(owner, eventtype, reason, message)
+	}
+}
+
+func setJobAnnotation(job *batchv1.Job, key, value string) {
+	if job.Annotations == nil {
+		job.Annotations = map[string]string{}
+	}
+	job.Annotations[key] = value
+}
+
+// hasJobSpecChanged returns true when the spec hash of the current Job differs from the expected one.
+// Jobs without spec hash, created before it was introduced, are considered unchanged.
+func hasJobSpecChanged(current, expected *batchv1.Job) bool {
+	currentHash, ok := current.Annotations[JobSpecHashAnnotation]
+	return ok && currentHash != expected.Annotations[JobSpecHashAnnotation]
+}
+
+// isJobFinished returns true when the provided Job completed or failed.
+func isJobFinished(job *batchv1.Job) bool {
+	return getJobCondition(job, batchv1.JobComplete) != nil || getJobCondition(job, batchv1.JobFailed) != nil
+}
+
+// getJobAttempt returns the attempt number of the provided Job.
+// Jobs without a valid attempt annotation are considered as the first attempt.
+func getJobAttempt(job *batchv1.Job) int {
+	return getJobCounter(job, JobAttemptAnnotation)
+}
+
+// getJobRun returns the run number of the provided Job.
+// Jobs without a valid run annotation are considered as the first run.
+func getJobRun(job *batchv1.Job) int {
+	return getJobCounter(job, JobRunAnnotation)
+}
+
+func getJobCounter(job *batchv1.Job, annotation string) int {
+	counter, err := strconv.Atoi(job.GetAnnotations()[annotation])
+	if err != nil || counter < 1 {
+		return 1
+	}
+	return counter
+}
+
+// getJobCondition returns the provided condition of the Job if it's true.
+func getJobCondition(job *batchv1.Job, conditionType batchv1.JobConditionType) *batchv1.JobCondition {
+	for i := range job.Status.Conditions {
+		condition := &job.Status.Conditions[i]
+		if condition.Type == conditionType && condition.Status == corev1.ConditionTrue {
+			return condition
 		}
 	}
-	return 0, nil
+	return nil
 }
@@ -0,0 +1,623 @@
+// Licensed to Alexandre VILAIN under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Alexandre VILAIN licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package reconciler_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/alexandrevilain/controller-tools/pkg/fake"
+	"github.com/alexandrevilain/controller-tools/pkg/reconciler"
+	"github.com/alexandrevilain/controller-tools/pkg/resource"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("JosbReconciler", func() {
+	var rec *reconciler.JosbReconciler
+	var recorder *record.FakeRecorder
+	var owner *appsv1.Deployment
+	var factory reconciler.JobBuilderFactory
+	var prefix string
+	var succeeded []string
+
+	newJob := func(name string) *reconciler.Job {
+		return &reconciler.Job{
+			Name:    name,
+			Command: []string{"echo", name},
+			// Like owners tracking succeeded jobs in their status, skip reported jobs.
+			Skip: func(runtime.Object) bool {
+				return slices.Contains(succeeded, name)
+			},
+			ReportSuccess: func(runtime.Object) error {
+				succeeded = append(succeeded, name)
+				return nil
+			},
+		}
+	}
+
+	// listJobs returns the runs of the provided job, latest first.
+	listJobs := func(name string) []batchv1.Job {
+		list := &batchv1.JobList{}
+		Expect(c.List(context.TODO(), list, client.InNamespace("default"), client.MatchingLabels{
+			reconciler.JobNameLabel:     name,
+			reconciler.JobOwnerUIDLabel: string(owner.UID),
+		})).To(Succeed())
+		run := func(job batchv1.Job) int {
+			run, _ := strconv.Atoi(job.Annotations[reconciler.JobRunAnnotation])
+			return run
+		}
+		slices.SortFunc(list.Items, func(a, b batchv1.Job) int {
+			return run(b) - run(a)
+		})
+		return list.Items
+	}
+
+	// getJob returns the latest run of the provided job.
+	getJob := func(name string) *batchv1.Job {
+		jobs := listJobs(name)
+		Expect(jobs).NotTo(BeEmpty())
+		return &jobs[0]
+	}
+
+	// finishJob sets the provided condition on the Job, like the Job controller does.
+	finishJob := func(name string, conditionType batchv1.JobConditionType, transitionTime time.Time) {
+		job := getJob(name)
+		now := metav1.NewTime(transitionTime)
+		job.Status.StartTime = &now
+		if conditionType == batchv1.JobComplete {
+			job.Status.Conditions = append(job.Status.Conditions, batchv1.JobCondition{
+				Type: batchv1.JobSuccessCriteriaMet, Status: corev1.ConditionTrue, LastTransitionTime: now,
+			})
+			job.Status.Succeeded = 1
+			job.Status.CompletionTime = &now
+		} else {
+			job.Status.Conditions = append(job.Status.Conditions, batchv1.JobCondition{
+				Type: batchv1.JobFailureTarget, Status: corev1.ConditionTrue, LastTransitionTime: now,
+			})
+			job.Status.Failed = 1
+		}
+		job.Status.Conditions = append(job.Status.Conditions, batchv1.JobCondition{
+			Type: conditionType, Status: corev1.ConditionTrue, LastTransitionTime: now,
+			Reason: "Test", Message: "finished by test",
+		})
+		Expect(c.Status().Update(context.TODO(), job)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		prefix = fmt.Sprintf("job-%d", rand.Int31()) //nolint:gosec
+		succeeded = []string{}
+		recorder = record.NewFakeRecorder(32)
+		owner = &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "fake-owner",
+				Namespace: "default",
+				UID:       types.UID(prefix),
+			},
+		}
+		factory = func(_ runtime.Object, _ *runtime.Scheme, name string, command []string) resource.Builder {
+			return fake.NewJobBuilder(fmt.Sprintf("%s-%s", prefix, name), "default", command)
+		}
+		rec = &reconciler.JosbReconciler{
+			Client:   c,
+			Scheme:   c.Scheme(),
+			Recorder: recorder,
+		}
+	})
+
+	It("runs jobs in order", func() {
+		jobs := []*reconciler.Job{newJob("first"), newJob("second")}
+
+		result, err := rec.Reconcile(context.TODO(), owner, factory, jobs)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(reconciler.DefaultRequeueAfter))
+		Expect(getJob("first").Annotations).To(HaveKeyWithValue(reconciler.JobAttemptAnnotation, "1"))
+
+		By("waiting for the running job")
+		result, err = rec.Reconcile(context.TODO(), owner, factory, jobs)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(reconciler.DefaultRequeueAfter))
+		Expect(succeeded).To(BeEmpty())
+
+		By("running the next job once the first one completed")
+		finishJob("first", batchv1.JobComplete, time.Now())
+		_, err = rec.Reconcile(context.TODO(), owner, factory, jobs)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(succeeded).To(Equal([]string{"first"}))
+		getJob("second")
+
+		finishJob("second", batchv1.JobComplete, time.Now())
+		result, err = rec.Reconcile(context.TODO(), owner, factory, jobs)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(BeZero())
+		Expect(succeeded).To(Equal([]string{"first", "second"}))
+	})
+
+	It("reports failed jobs", func() {
+		var reported *reconciler.JobFailedError
+		reports := 0
+		job := newJob("failing")
+		job.ReportFailure = func(_ runtime.Object, err *reconciler.JobFailedError) error {
+			reported = err
+			reports++
+			return nil
+		}
+		jobs := []*reconciler.Job{job, newJob("next")}
+
+		_, err := rec.Reconcile(context.TODO(), owner, factory, jobs)
+		Expect(err).NotTo(HaveOccurred())
+		finishJob("failing", batchv1.JobFailed, time.Now())
+
+		_, err = rec.Reconcile(context.TODO(), owner, factory, jobs)
+		var failedErr *reconciler.JobFailedError
+		Expect(errors.As(err, &failedErr)).To(BeTrue())
+		Expect(failedErr.Name).To(Equal("failing"))
+		Expect(failedErr.Attempts).To(Equal(1))
+		Expect(failedErr.Reason).To(Equal("Test"))
+		Expect(failedErr.Message).To(Equal("finished by test"))
+		Expect(reported).To(Equal(failedErr))
+		Expect(recorder.Events).To(Receive(HavePrefix("Warning JobFailed")))
+
+		By("not running next jobs")
+		Expect(listJobs("next")).To(BeEmpty())
+
+		By("reporting the failure only once")
+		_, err = rec.Reconcile(context.TODO(), owner, factory, jobs)
+		Expect(errors.As(err, &failedErr)).To(BeTrue())
+		Expect(reports).To(Equal(1))
+		Expect(recorder.Events).NotTo(Receive())
+		Expect(getJob("failing").Annotations).To(HaveKey(reconciler.JobFailureReportedAnnotation))
+	})
+
+	It("retries failed jobs with a backoff", func() {
+		rec.RetryPolicy = reconciler.JobRetryPolicy{
+			MaxRetries: 1,
+			Backoff:    time.Minute,
+		}
+		jobs := []*reconciler.Job{newJob("retried")}
+
+		_, err := rec.Reconcile(context.TODO(), owner, factory, jobs)
+		Expect(err).NotTo(HaveOccurred())
+		failed := getJob("retried")
+		finishJob("retried", batchv1.JobFailed, time.Now())
+
+		By("waiting for the backoff")
+		result, err := rec.Reconcile(context.TODO(), owner, factory, jobs)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(BeNumerically(">", 50*time.Second))
+		Expect(result.RequeueAfter).To(BeNumerically("<=", time.Minute))
+		Expect(getJob("retried").UID).To(Equal(failed.UID))
+
+		By("recreating the job once the backoff elapsed")
+		rec.RetryPolicy.Backoff = time.Millisecond
+		result, err = rec.Reconcile(context.TODO(), owner, factory, jobs)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(reconciler.DefaultRequeueAfter))
+		retried := getJob("retried")
+		Expect(retried.UID).NotTo(Equal(failed.UID))
+		Expect(retried.Annotations).To(HaveKeyWithValue(reconciler.JobAttemptAnnotation, "2"))
+		Expect(recorder.Events).To(Receive(HavePrefix("Normal JobRetried")))
+
+		By("failing once out of retries")
+		finishJob("retried", batchv1.JobFailed, time.Now().Add(-time.Minute))
+		_, err = rec.Reconcile(context.TODO(), owner, factory, jobs)
+		var failedErr *reconciler.JobFailedError
+		Expect(errors.As(err, &failedErr)).To(BeTrue())
+		Expect(failedErr.Attempts).To(Equal(2))
+	})
+
+	It("recreates jobs whose spec changed", func() {
+		jobs := []*reconciler.Job{newJob("changed")}
+
+		_, err := rec.Reconcile(context.TODO(), owner, factory, jobs)
+		Expect(err).NotTo(HaveOccurred())
+		previous := getJob("changed")
+		Expect(previous.Annotations).To(HaveKey(reconciler.JobSpecHashAnnotation))
+
+		By("not recreating unchanged jobs")
+		_, err = rec.Reconcile(context.TODO(), owner, factory, jobs)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(getJob("changed").UID).To(Equal(previous.UID))
+
+		By("recreating the job when its command changed")
+		jobs[0].Command = []string{"echo", "new command"}
+		_, err = rec.Reconcile(context.TODO(), owner, factory, jobs)
+		Expect(err).NotTo(HaveOccurred())
+
+		recreated := getJob("changed")
+		Expect(recreated.UID).NotTo(Equal(previous.UID))
+		Expect(recreated.Spec.Template.Spec.Containers[0].Command).To(Equal(jobs[0].Command))
+		Expect(recreated.Annotations[reconciler.JobSpecHashAnnotation]).NotTo(Equal(previous.Annotations[reconciler.JobSpecHashAnnotation]))
+		Expect(recorder.Events).To(Receive(HavePrefix("Normal JobRecreated")))
+	})
+
+	It("waits for the previous run to finish before recreating changed jobs", func() {
+		rec.WaitForPreviousRun = true
+		jobs := []*reconciler.Job{newJob("changed")}
+
+		_, err := rec.Reconcile(context.TODO(), owner, factory, jobs)
+		Expect(err).NotTo(HaveOccurred())
+		previous := getJob("changed")
+
+		jobs[0].Command = []string{"echo", "new command"}
+		result, err := rec.Reconcile(context.TODO(), owner, factory, jobs)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(reconciler.DefaultRequeueAfter))
+		Expect(getJob("changed").UID).To(Equal(previous.UID))
+
+		By("recreating the job once finished")
+		finishJob("changed", batchv1.JobComplete, time.Now())
+		_, err = rec.Reconcile(context.TODO(), owner, factory, jobs)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(getJob("changed").UID).NotTo(Equal(previous.UID))
+		Expect(succeeded).To(BeEmpty())
+	})
+
+	Context("with dependencies", func() {
+		It("runs jobs once their dependencies succeeded, up to MaxParallelism jobs at once", func() {
+			rec.MaxParallelism = 2
+			last := newJob("last")
+			last.DependsOn = []string{"first", "second", "third"}
+			jobs := []*reconciler.Job{last, newJob("first"), newJob("second"), newJob("third")}
+
+			result, err := rec.Reconcile(context.TODO(), owner, factory, jobs)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Progress).To(Equal(reconciler.JobsProgress{Total: 4, Running: 2, Pending: 2}))
+			Expect(result.RequeueAfter).To(Equal(reconciler.DefaultRequeueAfter))
+			Expect(listJobs("first")).To(HaveLen(1))
+			Expect(listJobs("second")).To(HaveLen(1))
+			Expect(listJobs("third")).To(BeEmpty())
+
+			By("running the next job once a slot is available")
+			finishJob("first", batchv1.JobComplete, time.Now())
+			result, err = rec.Reconcile(context.TODO(), owner, factory, jobs)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Progress).To(Equal(reconciler.JobsProgress{Total: 4, Succeeded: 1, Running: 2, Pending: 1}))
+			Expect(listJobs("third")).To(HaveLen(1))
+			Expect(listJobs("last")).To(BeEmpty())
+
+			By("running the dependent job once all its dependencies succeeded")
+			finishJob("second", batchv1.JobComplete, time.Now())
+			finishJob("third", batchv1.JobComplete, time.Now())
+			result, err = rec.Reconcile(context.TODO(), owner, factory, jobs)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Progress).To(Equal(reconciler.JobsProgress{Total: 4, Succeeded: 2, Skipped: 1, Running: 1}))
+			Expect(listJobs("last")).To(HaveLen(1))
+
+			finishJob("last", batchv1.JobComplete, time.Now())
+			result, err = rec.Reconcile(context.TODO(), owner, factory, jobs)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Done()).To(BeTrue())
+			Expect(result.RequeueAfter).To(BeZero())
+			Expect(result.Progress.String()).To(Equal("4/4 jobs done: 1 succeeded, 3 skipped, 0 running, 0 pending, 0 failed"))
+			Expect(succeeded).To(Equal([]string{"first", "second", "third", "last"}))
+		})
+
+		It("keeps running independent jobs when a job failed", func() {
+			dependent := newJob("dependent")
+			dependent.DependsOn = []string{"failing"}
+			jobs := []*reconciler.Job{newJob("failing"), dependent, newJob("independent")}
+
+			_, err := rec.Reconcile(context.TODO(), owner, factory, jobs)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(listJobs("independent")).To(HaveLen(1))
+
+			finishJob("failing", batchv1.JobFailed, time.Now())
+			result, err := rec.Reconcile(context.TODO(), owner, factory, jobs)
+			var failedErr *reconciler.JobFailedError
+			Expect(errors.As(err, &failedErr)).To(BeTrue())
+			Expect(failedErr.Name).To(Equal("failing"))
+			Expect(result.Progress).To(Equal(reconciler.JobsProgress{Total: 3, Failed: 1, Pending: 1, Running: 1}))
+			Expect(result.Done()).To(BeFalse())
+			Expect(listJobs("dependent")).To(BeEmpty())
+		})
+
+		It("returns the errors of every failed job", func() {
+			last := newJob("last")
+			last.DependsOn = []string{"first", "second"}
+			jobs := []*reconciler.Job{newJob("first"), newJob("second"), last}
+
+			_, err := rec.Reconcile(context.TODO(), owner, factory, jobs)
+			Expect(err).NotTo(HaveOccurred())
+
+			finishJob("first", batchv1.JobFailed, time.Now())
+			finishJob("second", batchv1.JobFailed, time.Now())
+			result, err := rec.Reconcile(context.TODO(), owner, factory, jobs)
+			Expect(result.Progress).To(Equal(reconciler.JobsProgress{Total: 3, Failed: 2, Pending: 1}))
+
+			var failedErr *reconciler.JobFailedError
+			Expect(errors.As(err, &failedErr)).To(BeTrue())
+			Expect(failedErr.Name).To(Equal("first"))
+
+			var jobsErr *reconciler.JobsFailedError
+			Expect(errors.As(err, &jobsErr)).To(BeTrue())
+			Expect(jobsErr.Errors).To(HaveLen(2))
+			Expect(jobsErr.Errors[1].Name).To(Equal("second"))
+		})
+
+		It("returns an error for invalid dependencies", func() {
+			first := newJob("first")
+			first.DependsOn = []string{"second"}
+			second := newJob("second")
+			second.DependsOn = []string{"first"}
+
+			_, err := rec.Reconcile(context.TODO(), owner, factory, []*reconciler.Job{first, second})
+			var cycleErr *reconciler.DependencyCycleError
+			Expect(errors.As(err, &cycleErr)).To(BeTrue())
+			Expect(cycleErr.Resources).To(ConsistOf("first", "second"))
+
+			second.DependsOn = []string{"unknown"}
+			_, err = rec.Reconcile(context.TODO(), owner, factory, []*reconciler.Job{first, second})
+			Expect(err).To(MatchError("job second depends on unknown job unknown"))
+			Expect(listJobs("first")).To(BeEmpty())
+		})
+	})
+
+	Context("with job statuses", func() {
+		// failLabeledPod creates a pod of the provided job whose container failed, like the Job controller does,
+		// labeled with the job's uid using the provided label.
+		failLabeledPod := func(name, label, message string) {
+			job := getJob(name)
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      fmt.Sprintf("%s-%d", job.Name, rand.Int31()), //nolint:gosec
+					Namespace: job.Namespace,
+					Labels:    map[string]string{label: string(job.UID)},
+				},
+				Spec: job.Spec.Template.Spec,
+			}
+			Expect(c.Create(context.TODO(), pod)).To(Succeed())
+
+			pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+				Name:  pod.Spec.Containers[0].Name,
+				Image: pod.Spec.Containers[0].Image,
+				State: corev1.ContainerState{
+					Terminated: &corev1.ContainerStateTerminated{
+						ExitCode:   1,
+						Message:    message,
+						FinishedAt: metav1.Now(),
+					},
+				},
+			}}
+			Expect(c.Status().Update(context.TODO(), pod)).To(Succeed())
+		}
+
+		// failPod creates a pod of the provided job whose container failed.
+		failPod := func(name, message string) {
+			failLabeledPod(name, batchv1.ControllerUidLabel, message)
+		}
+
+		It("reports the status of every job", func() {
+			jobs := []*reconciler.Job{newJob("first"), newJob("second")}
+
+			result, err := rec.Reconcile(context.TODO(), owner, factory, jobs)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Jobs).To(Equal([]reconciler.JobStatus{
+				{Name: "first", Phase: reconciler.JobPhaseRunning, JobName: prefix + "-first", Attempts: 1},
+				{Name: "second", Phase: reconciler.JobPhasePending},
+			}))
+
+			finishJob("first", batchv1.JobComplete, time.Now())
+			result, err = rec.Reconcile(context.TODO(), owner, factory, jobs)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Jobs).To(HaveLen(2))
+			Expect(result.Jobs[0].Phase).To(Equal(reconciler.JobPhaseSucceeded))
+			Expect(result.Jobs[0].StartTime).NotTo(BeNil())
+			Expect(result.Jobs[0].CompletionTime).NotTo(BeNil())
+			Expect(result.Jobs[1].Phase).To(Equal(reconciler.JobPhaseRunning))
+
+			By("keeping the times of skipped jobs whose runs are kept")
+			result, err = rec.Reconcile(context.TODO(), owner, factory, jobs)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Jobs[0].Phase).To(Equal(reconciler.JobPhaseSkipped))
+			Expect(result.Jobs[0].JobName).To(Equal(prefix + "-first"))
+			Expect(result.Jobs[0].CompletionTime).NotTo(BeNil())
+
+			status := result.Jobs[0].DeepCopy()
+			Expect(status).To(Equal(&result.Jobs[0]))
+			Expect(status.CompletionTime).NotTo(BeIdenticalTo(result.Jobs[0].CompletionTime))
+		})
+
+		It("reports why jobs failed", func() {
+			rec.RetryPolicy = reconciler.JobRetryPolicy{MaxRetries: 1, Backoff: time.Minute}
+			last := newJob("last")
+			last.DependsOn = []string{"failing", "podless"}
+			jobs := []*reconciler.Job{newJob("failing"), newJob("podless"), last}
+
+			_, err := rec.Reconcile(context.TODO(), owner, factory, jobs)
+			Expect(err).NotTo(HaveOccurred())
+
+			failPod("failing", "migration failed\n")
+			finishJob("failing", batchv1.JobFailed, time.Now())
+			finishJob("podless", batchv1.JobFailed, time.Now())
+			result, err := rec.Reconcile(context.TODO(), owner, factory, jobs)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Jobs[0].Phase).To(Equal(reconciler.JobPhaseRunning))
+			Expect(result.Jobs[0].CompletionTime).NotTo(BeNil())
+
+			By("reporting the termination message of the failed container")
+			Expect(result.Jobs[0].Message).To(Equal("migration failed"))
+
+			By("reporting the Job failure message when it has no failed pod")
+			Expect(result.Jobs[1].Message).To(Equal("Test: finished by test"))
+
+			By("caching the message in the Job")
+			Expect(getJob("failing").Annotations).To(HaveKeyWithValue(reconciler.JobFailureMessageAnnotation, "migration failed"))
+			Expect(c.DeleteAllOf(context.TODO(), &corev1.Pod{}, client.InNamespace("default"), client.MatchingLabels{
+				batchv1.ControllerUidLabel: string(getJob("failing").UID),
+			})).To(Succeed())
+			result, err = rec.Reconcile(context.TODO(), owner, factory, jobs)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Jobs[0].Message).To(Equal("migration failed"))
+		})
+
+		It("reports why jobs failed using pods of older Kubernetes versions", func() {
+			jobs := []*reconciler.Job{newJob("failing")}
+
+			_, err := rec.Reconcile(context.TODO(), owner, factory, jobs)
+			Expect(err).NotTo(HaveOccurred())
+
+			failLabeledPod("failing", "controller-uid", "migration failed")
+			finishJob("failing", batchv1.JobFailed, time.Now())
+			result, err := rec.Reconcile(context.TODO(), owner, factory, jobs)
+			var failedErr *reconciler.JobFailedError
+			Expect(errors.As(err, &failedErr)).To(BeTrue())
+			Expect(result.Jobs[0].Message).To(Equal("migration failed"))
+		})
+
+		It("falls back to the Job failure message when pods can't be listed", func() {
+			rec.Client = &podsForbiddenClient{Client: c}
+			jobs := []*reconciler.Job{newJob("failing")}
+
+			_, err := rec.Reconcile(context.TODO(), owner, factory, jobs)
+			Expect(err).NotTo(HaveOccurred())
+
+			failPod("failing", "migration failed")
+			finishJob("failing", batchv1.JobFailed, time.Now())
+			result, err := rec.Reconcile(context.TODO(), owner, factory, jobs)
+			var failedErr *reconciler.JobFailedError
+			Expect(errors.As(err, &failedErr)).To(BeTrue())
+			Expect(result.Jobs[0].Message).To(Equal("Test: finished by test"))
+			Expect(getJob("failing").Annotations).NotTo(HaveKey(reconciler.JobFailureMessageAnnotation))
+		})
+
+		It("reports the logs of failed containers without termination message", func() {
+			rec.PodLogs = reconciler.TailPodLogs(k8sfake.NewClientset(), 10)
+			jobs := []*reconciler.Job{newJob("failing")}
+
+			_, err := rec.Reconcile(context.TODO(), owner, factory, jobs)
+			Expect(err).NotTo(HaveOccurred())
+
+			finishJob("failing", batchv1.JobFailed, time.Now())
+			failPod("failing", "")
+			result, err := rec.Reconcile(context.TODO(), owner, factory, jobs)
+			Expect(err).To(HaveOccurred())
+			Expect(result.Jobs[0].Phase).To(Equal(reconciler.JobPhaseFailed))
+			// The fake clientset returns fixed logs.
+			Expect(result.Jobs[0].Message).To(Equal("fake logs"))
+		})
+	})
+
+	Context("with a retention policy", func() {
+		It("deletes previous runs by default", func() {
+			rec.RetryPolicy = reconciler.JobRetryPolicy{MaxRetries: 1, Backoff: time.Millisecond}
+			jobs := []*reconciler.Job{newJob("retried")}
+
+			_, err := rec.Reconcile(context.TODO(), owner, factory, jobs)
+			Expect(err).NotTo(HaveOccurred())
+			finishJob("retried", batchv1.JobFailed, time.Now().Add(-time.Minute))
+
+			_, err = rec.Reconcile(context.TODO(), owner, factory, jobs)
+			Expect(err).NotTo(HaveOccurred())
+
+			runs := listJobs("retried")
+			Expect(runs).To(HaveLen(1))
+			Expect(runs[0].Name).To(Equal(prefix + "-retried-2"))
+			Expect(runs[0].Annotations).To(HaveKeyWithValue(reconciler.JobRunAnnotation, "2"))
+		})
+
+		It("keeps the configured history of failed runs", func() {
+			rec.RetryPolicy = reconciler.JobRetryPolicy{MaxRetries: 2, Backoff: time.Millisecond}
+			rec.RetentionPolicy.FailedJobsHistoryLimit = 1
+			jobs := []*reconciler.Job{newJob("retried")}
+
+			_, err := rec.Reconcile(context.TODO(), owner, factory, jobs)
+			Expect(err).NotTo(HaveOccurred())
+
+			for i := 0; i < 2; i++ {
+				finishJob("retried", batchv1.JobFailed, time.Now().Add(-time.Minute))
+				_, err = rec.Reconcile(context.TODO(), owner, factory, jobs)
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			runs := listJobs("retried")
+			Expect(runs).To(HaveLen(2))
+			Expect(runs[0].Name).To(Equal(prefix + "-retried-3"))
+			Expect(runs[1].Name).To(Equal(prefix + "-retried-2"))
+		})
+
+		It("deletes succeeded jobs once their success was acknowledged", func() {
+			rec.RetentionPolicy.DeleteAfterSuccess = true
+			jobs := []*reconciler.Job{newJob("succeeded")}
+
+			_, err := rec.Reconcile(context.TODO(), owner, factory, jobs)
+			Expect(err).NotTo(HaveOccurred())
+			finishJob("succeeded", batchv1.JobComplete, time.Now())
+
+			By("keeping the job until its success is acknowledged")
+			_, err = rec.Reconcile(context.TODO(), owner, factory, jobs)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(succeeded).To(Equal([]string{"succeeded"}))
+			Expect(listJobs("succeeded")).To(HaveLen(1))
+
+			By("deleting the job without running it again")
+			_, err = rec.Reconcile(context.TODO(), owner, factory, jobs)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(listJobs("succeeded")).To(BeEmpty())
+
+			_, err = rec.Reconcile(context.TODO(), owner, factory, jobs)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(listJobs("succeeded")).To(BeEmpty())
+		})
+
+		It("sets the ttl of succeeded jobs once their success was acknowledged", func() {
+			var ttl int32 = 60
+			rec.RetentionPolicy.TTLSecondsAfterFinished = &ttl
+			jobs := []*reconciler.Job{newJob("succeeded")}
+
+			_, err := rec.Reconcile(context.TODO(), owner, factory, jobs)
+			Expect(err).NotTo(HaveOccurred())
+			finishJob("succeeded", batchv1.JobComplete, time.Now())
+
+			_, err = rec.Reconcile(context.TODO(), owner, factory, jobs)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(getJob("succeeded").Spec.TTLSecondsAfterFinished).To(BeNil())
+
+			_, err = rec.Reconcile(context.TODO(), owner, factory, jobs)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(getJob("succeeded").Spec.TTLSecondsAfterFinished).To(Equal(&ttl))
+		})
+	})
+})
+
+// podsForbiddenClient is a client which isn't allowed to list pods.
+type podsForbiddenClient struct {
+	client.Client
+}
+
+func (c *podsForbiddenClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	if _, ok := list.(*corev1.PodList); ok {
+		return errors.New("pods are forbidden")
+	}
+	return c.Client.List(ctx, list, opts...)
+}
@@ -0,0 +1,172 @@
+// Licensed to Alexandre VILAIN under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Alexandre VILAIN licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package reconciler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	batchv1 "k8s.io/api/batch/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// JobRetentionPolicy defines how finished Jobs created by the JosbReconciler are cleaned up.
+//
+// A Job's success is acknowledged once its Skip function returns true, meaning that the owner recorded it.
+// Runs of succeeded Jobs are only deleted once acknowledged, so that they aren't run again.
+type JobRetentionPolicy struct {
+	// SuccessfulJobsHistoryLimit is the number of previous successful runs kept for every Job.
+	SuccessfulJobsHistoryLimit int
+	// FailedJobsHistoryLimit is the number of previous failed runs kept for every Job.
+	FailedJobsHistoryLimit int
+	// TTLSecondsAfterFinished is set on the runs of acknowledged succeeded Jobs,
+	// so that they're deleted by the api server once the duration elapsed.
+	TTLSecondsAfterFinished *int32
+	// DeleteAfterSuccess deletes the runs of acknowledged succeeded Jobs.
+	DeleteAfterSuccess bool
+}
+
+// listJobRuns returns the runs of the provided Job which aren't being deleted, latest first.
+func (r *JosbReconciler) listJobRuns(ctx context.Context, owner client.Object, job *Job, expectedJob *batchv1.Job) ([]*batchv1.Job, error) {
+	list := &batchv1.JobList{}
+	err := r.Client.List(ctx, list,
+		client.InNamespace(expectedJob.Namespace),
+		client.MatchingLabels{JobNameLabel: job.Name, JobOwnerUIDLabel: string(owner.GetUID())},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("can't list job runs: %w", err)
+	}
+
+	runs := []*batchv1.Job{}
+	for i := range list.Items {
+		if list.Items[i].DeletionTimestamp.IsZero() {
+			runs = append(runs, &list.Items[i])
+		}
+	}
+
+	// Jobs created before runs were labeled are only found by name.
+	if len(runs) == 0 {
+		legacy := &batchv1.Job{}
+		err := r.Client.Get(ctx, client.ObjectKeyFromObject(expectedJob), legacy)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("can't get job: %w", err)
+		}
+		if err == nil && legacy.DeletionTimestamp.IsZero() {
+			runs = append(runs, legacy)
+		}
+	}
+
+	sort.SliceStable(runs, func(i, j int) bool {
+		return getJobRun(runs[i]) > getJobRun(runs[j])
+	})
+
+	return runs, nil
+}
+
+// pruneJobRuns deletes the provided previous runs of a Job, latest first, according to the retention policy.
+// Unfinished runs are always deleted, as they're outdated.
+func (r *JosbReconciler) pruneJobRuns(ctx context.Context, runs []*batchv1.Job) error {
+	succeeded, failed := 0, 0
+	for _, run := range runs {
+		switch {
+		case getJobCondition(run, batchv1.JobComplete) != nil:
+			succeeded++
+			if succeeded <= r.RetentionPolicy.SuccessfulJobsHistoryLimit {
+				continue
+			}
+		case getJobCondition(run, batchv1.JobFailed) != nil:
+			failed++
+			if failed <= r.RetentionPolicy.FailedJobsHistoryLimit {
+				continue
+			}
+		}
+
+		err := r.deleteJobRun(ctx, run)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// cleanupSucceededJob deletes the provided runs of a skipped Job, latest first,
+// or sets their TTL according to the retention policy, if its success was acknowledged.
+func (r *JosbReconciler) cleanupSucceededJob(ctx context.Context, runs []*batchv1.Job) error {
+	if !r.RetentionPolicy.DeleteAfterSuccess && r.RetentionPolicy.TTLSecondsAfterFinished == nil {
+		return nil
+	}
+
+	// The job may be skipped for another reason than its success.
+	if len(runs) == 0 || getJobCondition(runs[0], batchv1.JobComplete) == nil {
+		return nil
+	}
+
+	for _, run := range runs {
+		if !isJobFinished(run) {
+			continue
+		}
+
+		if r.RetentionPolicy.DeleteAfterSuccess {
+			err := r.deleteJobRun(ctx, run)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		err := r.setJobTTL(ctx, run, *r.RetentionPolicy.TTLSecondsAfterFinished)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteJobRun deletes the provided Job, its pods are deleted in the background.
+func (r *JosbReconciler) deleteJobRun(ctx context.Context, run *batchv1.Job) error {
+	log.FromContext(ctx).V(1).Info("Deleting job run", "name", run.Name)
+
+	err := r.Client.Delete(ctx, run, client.PropagationPolicy(metav1.DeletePropagationBackground))
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("can't delete job: %w", err)
+	}
+
+	return nil
+}
+
+// setJobTTL sets the TTL after which the provided finished Job is deleted by the api server.
+func (r *JosbReconciler) setJobTTL(ctx context.Context, run *batchv1.Job, ttl int32) error {
+	if run.Spec.TTLSecondsAfterFinished != nil && *run.Spec.TTLSecondsAfterFinished == ttl {
+		return nil
+	}
+
+	patch := client.MergeFrom(run.DeepCopy())
+	run.Spec.TTLSecondsAfterFinished = &ttl
+	err := r.Client.Patch(ctx, run, patch)
+	if err != nil {
+		return fmt.Errorf("can't set job ttl: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,226 @@
+// Licensed to Alexandre VILAIN under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Alexandre VILAIN licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package reconciler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// legacyJobControllerUIDLabel is the label holding the uid of the Job owning a pod, set by Kubernetes versions before 1.27.
+const legacyJobControllerUIDLabel = "controller-uid"
+
+// JobStatus is the status of a job reconciled by the JosbReconciler.
+// It can be embedded in custom resources status.
+type JobStatus struct {
+	// Name is the name of the job.
+	Name string `json:"name"`
+	// Phase is the phase of the job.
+	Phase JobPhase `json:"phase"`
+	// JobName is the name of the latest run of the job.
+	// It's empty when the job never ran or when its runs were deleted.
+	JobName string `json:"jobName,omitempty"`
+	// Attempts is the number of attempts of the latest run of the job.
+	Attempts int32 `json:"attempts,omitempty"`
+	// StartTime is the time the latest run of the job started.
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// CompletionTime is the time the latest run of the job succeeded or failed.
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// Message explains why the latest run of the job failed.
+	// It's the termination message or the logs tail of its failed container if any, the Job's failure message otherwise.
+	Message string `json:"message,omitempty"`
+}
+
+func (in *JobStatus) DeepCopyInto(out *JobStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		out.StartTime = in.StartTime.DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		out.CompletionTime = in.CompletionTime.DeepCopy()
+	}
+}
+
+func (in *JobStatus) DeepCopy() *JobStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(JobStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *JobsProgress) DeepCopyInto(out *JobsProgress) {
+	*out = *in
+}
+
+func (in *JobsProgress) DeepCopy() *JobsProgress {
+	if in == nil {
+		return nil
+	}
+	out := new(JobsProgress)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// PodLogsFunc returns the logs of the provided pod's container.
+type PodLogsFunc func(ctx context.Context, pod *corev1.Pod, container string) (string, error)
+
+// TailPodLogs returns a PodLogsFunc getting the provided number of last lines of logs using the provided clientset.
+func TailPodLogs(clientset kubernetes.Interface, lines int64) PodLogsFunc {
+	return func(ctx context.Context, pod *corev1.Pod, container string) (string, error) {
+		logs, err := clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+			Container: container,
+			TailLines: &lines,
+		}).DoRaw(ctx)
+		if err != nil {
+			return "", fmt.Errorf("can't get pod logs: %w", err)
+		}
+
+		return strings.TrimSpace(string(logs)), nil
+	}
+}
+
+// jobStatus returns the status of the provided job, computed from its latest run.
+func (r *JosbReconciler) jobStatus(ctx context.Context, state *jobState, phase JobPhase) JobStatus {
+	status := JobStatus{
+		Name:  state.job.Name,
+		Phase: phase,
+	}
+
+	if len(state.runs) == 0 {
+		return status
+	}
+
+	run := state.runs[0]
+	status.JobName = run.Name
+	status.Attempts = int32(getJobAttempt(run)) //nolint:gosec
+	status.StartTime = run.Status.StartTime.DeepCopy()
+	status.CompletionTime = run.Status.CompletionTime.DeepCopy()
+
+	failed := getJobCondition(run, batchv1.JobFailed)
+	if failed == nil {
+		return status
+	}
+
+	// Failed Jobs have no completion time.
+	status.CompletionTime = failed.LastTransitionTime.DeepCopy()
+	status.Message = r.jobFailureMessage(ctx, run, failed)
+
+	return status
+}
+
+// jobFailureMessage returns the message explaining why the provided Job failed.
+// It's resolved once from the Job's pods, then cached in the Job's annotations.
+// The message of the Job's Failed condition is returned when it can't be resolved.
+func (r *JosbReconciler) jobFailureMessage(ctx context.Context, job *batchv1.Job, failed *batchv1.JobCondition) string {
+	logger := log.FromContext(ctx)
+
+	if message, ok := job.Annotations[JobFailureMessageAnnotation]; ok {
+		return message
+	}
+
+	message, err := r.failedContainerMessage(ctx, job)
+	if err != nil {
+		// The message is resolved again on next reconciliations.
+		logger.V(1).Info("Can't get job failure message", "name", job.Name, "error", err.Error())
+		return fmt.Sprintf("%s: %s", failed.Reason, failed.Message)
+	}
+
+	if message == "" {
+		message = fmt.Sprintf("%s: %s", failed.Reason, failed.Message)
+	}
+
+	patch := client.MergeFrom(job.DeepCopy())
+	setJobAnnotation(job, JobFailureMessageAnnotation, message)
+	err = r.Client.Patch(ctx, job, patch)
+	if err != nil {
+		logger.V(1).Info("Can't cache job failure message", "name", job.Name, "error", err.Error())
+	}
+
+	return message
+}
+
+// failedContainerMessage returns the termination message of the latest failed container of the provided Job's pods,
+// or an empty string if there is none.
+// When the container has no termination message, its logs are returned if PodLogs is set.
+func (r *JosbReconciler) failedContainerMessage(ctx context.Context, job *batchv1.Job) (string, error) {
+	pods, err := r.jobPods(ctx, job)
+	if err != nil {
+		return "", err
+	}
+
+	var failedPod *corev1.Pod
+	var failedContainer *corev1.ContainerStatus
+	for i := range pods {
+		pod := &pods[i]
+		for j := range pod.Status.ContainerStatuses {
+			container := &pod.Status.ContainerStatuses[j]
+			terminated := container.State.Terminated
+			if terminated == nil || terminated.ExitCode == 0 {
+				continue
+			}
+
+			if failedContainer == nil || failedContainer.State.Terminated.FinishedAt.Before(&terminated.FinishedAt) {
+				failedPod = pod
+				failedContainer = container
+			}
+		}
+	}
+
+	if failedContainer == nil {
+		return "", nil
+	}
+
+	message := strings.TrimSpace(failedContainer.State.Terminated.Message)
+	if message != "" || r.PodLogs == nil {
+		return message, nil
+	}
+
+	// Logs may be unavailable, like when the pod's node is gone.
+	return r.PodLogs(ctx, failedPod, failedContainer.Name)
+}
+
+// jobPods returns the pods of the provided Job.
+// Pods created by Kubernetes versions before 1.27 only have the legacy controller uid label.
+func (r *JosbReconciler) jobPods(ctx context.Context, job *batchv1.Job) ([]corev1.Pod, error) {
+	for _, label := range []string{batchv1.ControllerUidLabel, legacyJobControllerUIDLabel} {
+		pods := &corev1.PodList{}
+		err := r.Client.List(ctx, pods,
+			client.InNamespace(job.Namespace),
+			client.MatchingLabels{label: string(job.UID)},
+		)
+		if err != nil {
+			return nil, fmt.Errorf("can't list job pods: %w", err)
+		}
+
+		if len(pods.Items) > 0 {
+			return pods.Items, nil
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,133 @@
+// Licensed to Alexandre VILAIN under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Alexandre VILAIN licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package reconciler
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// OwnerReferencePolicy defines how resources created by builders are linked to their owner.
+type OwnerReferencePolicy string
+
+const (
+	// OwnerReferencePolicyNone doesn't link resources to their owner.
+	OwnerReferencePolicyNone OwnerReferencePolicy = "None"
+	// OwnerReferencePolicyController sets the owner as the controller reference of the resources.
+	OwnerReferencePolicyController OwnerReferencePolicy = "Controller"
+	// OwnerReferencePolicyOwner adds the owner as a non-controller owner reference of the resources.
+	OwnerReferencePolicyOwner OwnerReferencePolicy = "Owner"
+	// OwnerReferencePolicyLabels tracks the owner using labels and annotations set on the resources.
+	// Resources tracked this way are not garbage collected by the api server.
+	OwnerReferencePolicyLabels OwnerReferencePolicy = "Labels"
+)
+
+const (
+	// OwnerUIDLabel is set on resources tracked by labels, its value is the owner's UID.
+	OwnerUIDLabel = "controller-tools.alexandrevilain.dev/owner-uid"
+	// OwnerKindAnnotation is set on resources tracked by labels, its value is the owner's kind.
+	OwnerKindAnnotation = "controller-tools.alexandrevilain.dev/owner-kind"
+	// OwnerNameAnnotation is set on resources tracked by labels, its value is the owner's name.
+	OwnerNameAnnotation = "controller-tools.alexandrevilain.dev/owner-name"
+	// OwnerNamespaceAnnotation is set on resources tracked by labels, its value is the owner's namespace.
+	OwnerNamespaceAnnotation = "controller-tools.alexandrevilain.dev/owner-namespace"
+)
+
+// setOwner links the provided object to its owner according to the reconciler's owner reference policy.
+// Owner references can't cross namespaces nor be set from a namespaced owner on a cluster-scoped object,
+// in those cases the object falls back to being tracked using labels.
+func (r *Reconciler) setOwner(owner, object client.Object) error {
+	switch r.OwnerReferencePolicy {
+	case "", OwnerReferencePolicyNone:
+		return nil
+	case OwnerReferencePolicyLabels:
+		return r.setOwnerLabels(owner, object)
+	case OwnerReferencePolicyController, OwnerReferencePolicyOwner:
+		ok, err := r.canBeOwnerReferenced(owner, object)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			return r.setOwnerLabels(owner, object)
+		}
+
+		if r.OwnerReferencePolicy == OwnerReferencePolicyController {
+			return controllerutil.SetControllerReference(owner, object, r.Scheme)
+		}
+		return controllerutil.SetOwnerReference(owner, object, r.Scheme)
+	default:
+		return fmt.Errorf("unknown owner reference policy: %s", r.OwnerReferencePolicy)
+	}
+}
+
+// setOwnerLabels sets the owner tracking label and annotations on the provided object.
+func (r *Reconciler) setOwnerLabels(owner, object client.Object) error {
+	gvk, err := apiutil.GVKForObject(owner, r.Scheme)
+	if err != nil {
+		return err
+	}
+
+	labels := object.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[OwnerUIDLabel] = string(owner.GetUID())
+	object.SetLabels(labels)
+
+	annotations := object.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[OwnerKindAnnotation] = gvk.GroupKind().String()
+	annotations[OwnerNameAnnotation] = owner.GetName()
+	annotations[OwnerNamespaceAnnotation] = owner.GetNamespace()
+	object.SetAnnotations(annotations)
+
+	return nil
+}
+
+// canBeOwnerReferenced returns whenever the owner can be set as an owner reference of the provided object.
+// Scopes are resolved using the client's RESTMapper: cluster-scoped owners can own any object,
+// namespaced owners can only own namespaced objects in their namespace.
+func (r *Reconciler) canBeOwnerReferenced(owner, object client.Object) (bool, error) {
+	ownerNamespaced, err := r.Client.IsObjectNamespaced(owner)
+	if err != nil {
+		return false, fmt.Errorf("can't determine owner scope: %w", err)
+	}
+
+	if !ownerNamespaced {
+		return true, nil
+	}
+
+	objectNamespaced, err := r.Client.IsObjectNamespaced(object)
+	if err != nil {
+		return false, fmt.Errorf("can't determine object scope: %w", err)
+	}
+
+	return objectNamespaced && owner.GetNamespace() == object.GetNamespace(), nil
+}
+
+// isTrackedByAnotherOwner returns whenever the provided object is tracked using labels by another owner.
+func isTrackedByAnotherOwner(owner, object client.Object) bool {
+	uid, ok := object.GetLabels()[OwnerUIDLabel]
+	return ok && uid != string(owner.GetUID())
+}
@@ -0,0 +1,301 @@
+// Licensed to Alexandre VILAIN under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Alexandre VILAIN licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package reconciler
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// PruneOptions configures the deletion of resources previously managed for an owner
+// which are no longer produced by any builder.
+// Managed resources are recorded in an inventory ConfigMap, using the same format as cli-utils.
+type PruneOptions struct {
+	// DryRun only logs resources that would be pruned, without deleting them.
+	DryRun bool
+	// Skip lists kinds that are never pruned.
+	Skip []schema.GroupKind
+	// InventoryNamespace is the namespace where the inventory of cluster-scoped owners is stored.
+	InventoryNamespace string
+}
+
+const (
+	// InventoryLabel is set on inventory ConfigMaps, its value is the owner's UID.
+	InventoryLabel = "controller-tools.alexandrevilain.dev/inventory"
+
+	inventoryFieldSeparator  = "_"
+	inventoryColonTranscoded = "__"
+)
+
+// inventoryEntry identifies an object recorded in an inventory.
+type inventoryEntry struct {
+	Namespace string
+	Name      string
+	GroupKind schema.GroupKind
+}
+
+func newInventoryEntry(obj client.Object, gvk schema.GroupVersionKind) inventoryEntry {
+	return inventoryEntry{
+		Namespace: obj.GetNamespace(),
+		Name:      obj.GetName(),
+		GroupKind: gvk.GroupKind(),
+	}
+}
+
+// String returns the entry as a ConfigMap key: "<namespace>_<name>_<group>_<kind>".
+func (e inventoryEntry) String() string {
+	name := strings.ReplaceAll(e.Name, ":", inventoryColonTranscoded)
+	return strings.Join([]string{e.Namespace, name, e.GroupKind.Group, e.GroupKind.Kind}, inventoryFieldSeparator)
+}
+
+// newObjectError returns an error related to the object identified by the entry, of the provided kind.
+func (e inventoryEntry) newObjectError(gvk schema.GroupVersionKind, err error) *ObjectError {
+	return &ObjectError{
+		GVK:             gvk,
+		Key:             client.ObjectKey{Namespace: e.Namespace, Name: e.Name},
+		OperationResult: OperationResultDeleted,
+		Err:             err,
+	}
+}
+
+// parseInventoryEntry parses an entry previously formatted using inventoryEntry.String.
+func parseInventoryEntry(s string) (inventoryEntry, error) {
+	parts := strings.Split(s, inventoryFieldSeparator)
+	if len(parts) < 4 {
+		return inventoryEntry{}, fmt.Errorf("invalid inventory entry: %s", s)
+	}
+
+	name := strings.Join(parts[1:len(parts)-2], inventoryFieldSeparator)
+
+	return inventoryEntry{
+		Namespace: parts[0],
+		Name:      strings.ReplaceAll(name, inventoryColonTranscoded, ":"),
+		GroupKind: schema.GroupKind{
+			Group: parts[len(parts)-2],
+			Kind:  parts[len(parts)-1],
+		},
+	}, nil
+}
+
+// prune deletes resources recorded in the owner's inventory which are not in the desired set,
+// then records the desired set in the inventory. It returns the results of deleted resources.
+// Errors related to a resource which could not be pruned are ObjectErrors.
+// Resources which could not be pruned are kept in the inventory to be retried on the next reconcile.
+// In dry-run mode, the inventory is not updated.
+func (r *Reconciler) prune(ctx context.Context, owner client.Object, desired []inventoryEntry) ([]ResourceResult, error) {
+	logger := log.FromContext(ctx)
+
+	inventory, err := r.getInventory(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := map[string]string{}
+	for _, entry := range desired {
+		keys[entry.String()] = ""
+	}
+
+	pruned := []ResourceResult{}
+	errs := []error{}
+	for _, key := range sortedInventoryKeys(inventory) {
+		if _, ok := keys[key]; ok {
+			continue
+		}
+
+		entry, err := parseInventoryEntry(key)
+		if err != nil {
+			return nil, err
+		}
+
+		if slices.Contains(r.Prune.Skip, entry.GroupKind) {
+			logger.V(1).Info("Skipping prune of resource", "kind", entry.GroupKind, "name", entry.Name, "namespace", entry.Namespace)
+			continue
+		}
+
+		if r.Prune.DryRun {
+			logger.Info("Resource would be pruned", "kind", entry.GroupKind, "name", entry.Name, "namespace", entry.Namespace)
+			keys[key] = ""
+			continue
+		}
+
+		result, err := r.pruneEntry(ctx, owner, entry)
+		if err != nil {
+			errs = append(errs, err)
+			keys[key] = ""
+			continue
+		}
+
+		if result != nil {
+			pruned = append(pruned, *result)
+		}
+	}
+
+	if !r.DryRun && (inventory.GetResourceVersion() == "" || !maps.Equal(inventory.Data, keys)) {
+		inventory.Data = keys
+
+		err = r.saveInventory(ctx, owner, inventory)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return pruned, kerrors.NewAggregate(errs)
+}
+
+// pruneEntry deletes the object identified by the provided inventory entry.
+// It returns nil if the object doesn't exist anymore.
+func (r *Reconciler) pruneEntry(ctx context.Context, owner client.Object, entry inventoryEntry) (*ResourceResult, error) {
+	mapping, err := r.Client.RESTMapper().RESTMapping(entry.GroupKind)
+	if err != nil {
+		// The kind is no longer served by the api server, nothing to prune.
+		if apimeta.IsNoMatchError(err) {
+			return nil, nil
+		}
+		return nil, entry.newObjectError(entry.GroupKind.WithVersion(""), err)
+	}
+
+	var obj client.Object = &metav1.PartialObjectMetadata{}
+	if r.DryRun {
+		// The whole object is needed to show it in the diff.
+		obj = &unstructured.Unstructured{}
+	}
+	obj.GetObjectKind().SetGroupVersionKind(mapping.GroupVersionKind)
+	obj.SetName(entry.Name)
+	obj.SetNamespace(entry.Namespace)
+
+	result := &ResourceResult{
+		Object:          obj,
+		GVK:             mapping.GroupVersionKind,
+		OperationResult: OperationResultDeleted,
+	}
+
+	if r.DryRun {
+		err = r.Client.Get(ctx, client.ObjectKeyFromObject(obj), obj)
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, entry.newObjectError(mapping.GroupVersionKind, fmt.Errorf("can't get resource to prune: %w", err))
+		}
+
+		err = r.setDiff(result, obj, nil)
+		if err != nil {
+			return nil, entry.newObjectError(mapping.GroupVersionKind, err)
+		}
+	}
+
+	err = r.Client.Delete(ctx, obj, r.deleteOptions()...)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+
+	r.logAndRecordOperationResult(ctx, owner, obj, OperationResultDeleted, err)
+	if err != nil {
+		return nil, entry.newObjectError(mapping.GroupVersionKind, fmt.Errorf("can't prune resource: %w", err))
+	}
+
+	return result, nil
+}
+
+// getInventory returns the inventory ConfigMap of the provided owner.
+// If the inventory doesn't exist yet, an empty one is returned.
+func (r *Reconciler) getInventory(ctx context.Context, owner client.Object) (*corev1.ConfigMap, error) {
+	gvk, err := apiutil.GVKForObject(owner, r.Scheme)
+	if err != nil {
+		return nil, err
+	}
+
+	namespace := owner.GetNamespace()
+	if namespace == "" {
+		namespace = r.Prune.InventoryNamespace
+	}
+	if namespace == "" {
+		return nil, fmt.Errorf("can't store inventory of cluster-scoped %s %s: no inventory namespace provided", gvk.Kind, owner.GetName())
+	}
+
+	inventory := &corev1.ConfigMap{}
+	key := client.ObjectKey{
+		Name:      fmt.Sprintf("%s-%s-inventory", strings.ToLower(gvk.Kind), owner.GetName()),
+		Namespace: namespace,
+	}
+
+	err = r.Client.Get(ctx, key, inventory)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("can't get inventory: %w", err)
+		}
+
+		inventory.SetName(key.Name)
+		inventory.SetNamespace(key.Namespace)
+	}
+
+	return inventory, nil
+}
+
+// saveInventory creates or updates the provided inventory.
+// The inventory is owned by the owner so it gets garbage collected with it.
+func (r *Reconciler) saveInventory(ctx context.Context, owner client.Object, inventory *corev1.ConfigMap) error {
+	labels := inventory.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[InventoryLabel] = string(owner.GetUID())
+	inventory.SetLabels(labels)
+
+	err := controllerutil.SetControllerReference(owner, inventory, r.Scheme)
+	if err != nil {
+		return err
+	}
+
+	if inventory.GetResourceVersion() == "" {
+		err = r.Client.Create(ctx, inventory)
+	} else {
+		err = r.Client.Update(ctx, inventory)
+	}
+	if err != nil {
+		return fmt.Errorf("can't save inventory: %w", err)
+	}
+
+	return nil
+}
+
+// sortedInventoryKeys returns inventory keys in a stable order.
+func sortedInventoryKeys(inventory *corev1.ConfigMap) []string {
+	keys := make([]string, 0, len(inventory.Data))
+	for key := range inventory.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,73 @@
+// Licensed to Alexandre VILAIN under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Alexandre VILAIN licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package reconciler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestInventoryEntry(t *testing.T) {
+	tests := map[string]struct {
+		entry       inventoryEntry
+		expectedKey string
+	}{
+		"namespaced core resource": {
+			entry: inventoryEntry{
+				Namespace: "default",
+				Name:      "test",
+				GroupKind: schema.GroupKind{Kind: "ConfigMap"},
+			},
+			expectedKey: "default_test__ConfigMap",
+		},
+		"namespaced resource": {
+			entry: inventoryEntry{
+				Namespace: "default",
+				Name:      "test",
+				GroupKind: schema.GroupKind{Group: "apps", Kind: "Deployment"},
+			},
+			expectedKey: "default_test_apps_Deployment",
+		},
+		"cluster-scoped resource with colons in its name": {
+			entry: inventoryEntry{
+				Name:      "system:test",
+				GroupKind: schema.GroupKind{Group: "rbac.authorization.k8s.io", Kind: "ClusterRole"},
+			},
+			expectedKey: "_system__test_rbac.authorization.k8s.io_ClusterRole",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(tt *testing.T) {
+			key := test.entry.String()
+			assert.Equal(tt, test.expectedKey, key)
+
+			parsed, err := parseInventoryEntry(key)
+			require.NoError(tt, err)
+			assert.Equal(tt, test.entry, parsed)
+		})
+	}
+}
+
+func TestParseInvalidInventoryEntry(t *testing.T) {
+	_, err := parseInventoryEntry("default_test")
+	assert.Error(t, err)
+}
@@ -19,6 +19,7 @@ package reconciler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/alexandrevilain/controller-tools/pkg/discovery"
@@ -26,9 +27,11 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -40,24 +43,79 @@ type Reconciler struct {
 	Scheme    *runtime.Scheme
 	Recorder  record.EventRecorder
 	Discovery discovery.Manager
+	// ApplyMode defines how resources are written to the api server.
+	// Defaults to ApplyModeUpdate.
+	ApplyMode ApplyMode
+	// FieldManager is the field manager used when resources are applied server-side.
+	// Defaults to DefaultFieldManager.
+	FieldManager string
+	// ForceConflicts makes server-side apply take ownership of fields managed by other field managers
+	// instead of failing with a conflict.
+	ForceConflicts bool
+	// OwnerReferencePolicy defines how resources created by builders are linked to their owner.
+	// Defaults to OwnerReferencePolicyNone.
+	OwnerReferencePolicy OwnerReferencePolicy
+	// Prune enables the deletion of resources previously managed for an owner
+	// which are no longer produced by any builder. Pruning is disabled when nil.
+	Prune *PruneOptions
+	// IgnoredFields contains, by kind, the paths of fields keeping their live value when resources are updated,
+	// like replicas managed by an HorizontalPodAutoscaler (see resource.IgnoreFields for the path syntax).
+	// They're added to the fields returned by builders implementing resource.FieldIgnorer.
+	// Ignored fields are only used with ApplyModeUpdate.
+	IgnoredFields map[schema.GroupKind][]string
+	// IgnoreServerDefaults makes fields defaulted by the api server for core workloads keep their live value
+	// when they're left unset by builders (see resource.ServerDefaultedFields).
+	// It's only used with ApplyModeUpdate.
+	IgnoreServerDefaults bool
+	// DryRun makes ReconcileBuilders send all writes to the api server in dry-run mode, so that nothing is
+	// persisted, and report the difference between live and desired objects in the result.
+	// The inventory used for pruning is not updated and no event is recorded.
+	DryRun bool
+	// MaxConcurrency is the maximum number of resources reconciled concurrently by ReconcileBuilders.
+	// Resources are reconciled concurrently only once all their dependencies are reconciled. Errors of resources
+	// reconciled concurrently are aggregated, while events and logs are reported once all resources having the same
+	// dependency depth are reconciled, in reconciliation order, to keep them deterministic.
+	// Defaults to 1, meaning that resources are reconciled one after the other.
+	MaxConcurrency int
+	// ErrorPolicy defines how errors are handled by ReconcileBuilders.
+	// Defaults to ErrorPolicyFailFast.
+	ErrorPolicy ErrorPolicy
+	// WaitForDependencies delays the creation of resources until all their dependencies are ready.
+	// Resources waiting for their dependencies are reported with the OperationResultWaiting result.
+	WaitForDependencies bool
 }
 
 type reconcileResource struct {
-	builder resource.Builder
-	current client.Object
-	found   bool
+	builder   resource.Builder
+	gvk       schema.GroupVersionKind
+	key       client.ObjectKey
+	current   client.Object
+	found     bool
+	supported bool
+	// namespaced is false for cluster-scoped resources.
+	namespaced bool
+	// err is the error which occurred while fetching the current object, if any.
+	err error
 }
 
+// ReconcileBuilder reconciles the resource produced by the provided builder and returns it.
+// Like ReconcileBuilders, it honors the apply mode and dry-run mode.
 func (r *Reconciler) ReconcileBuilder(ctx context.Context, owner client.Object, builder resource.Builder) (client.Object, error) {
-	res := builder.Build()
-	result, err := controllerutil.CreateOrUpdate(ctx, r.Client, res, func() error {
-		return builder.Update(res)
-	})
-	r.logAndRecordOperationResult(ctx, owner, res, result, err)
-	return res, err
+	res, err := r.getReconcileResource(ctx, builder)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := r.reconcileResource(ctx, owner, res)
+	r.logAndRecordOperationResult(ctx, owner, res.current, result, err)
+	return res.current, err
 }
 
-func (r *Reconciler) ReconcileBuilders(ctx context.Context, owner client.Object, builders []resource.Builder) ([]client.Object, error) {
+// ReconcileBuilders reconciles resources produced by the provided builders.
+// It returns the result of every builder's resource reconciliation, as well as their aggregated readiness.
+// Using ErrorPolicyBestEffort, the result is returned along with a ReconcileError when some resources failed,
+// including resources which couldn't be fetched or pruned.
+func (r *Reconciler) ReconcileBuilders(ctx context.Context, owner client.Object, builders []resource.Builder) (*Result, error) {
 	logger := log.FromContext(ctx)
 
 	resources, err := r.getReconcileResourceFromBuilders(ctx, builders)
@@ -65,117 +123,521 @@ func (r *Reconciler) ReconcileBuilders(ctx context.Context, owner client.Object,
 		return nil, err
 	}
 
+	// Using the best effort error policy, resources which couldn't be fetched are reported as failed.
+	if !r.bestEffort() {
+		err = fetchErrors(resources)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Resources are reconciled after their dependencies.
+	resources, err = r.sortResourcesByDependencies(resources)
+	if err != nil {
+		return nil, err
+	}
+
 	logger.Info("Reconciling resources", "count", len(resources))
 
-	objects := []client.Object{}
+	levels, err := r.reconciliationLevels(resources)
+	if err != nil {
+		return nil, err
+	}
 
-	for _, res := range resources {
-		// If the builder isn't enabled, check if it needs to be deleted, then skip iteration.
-		if !res.builder.Enabled() {
-			if res.found {
-				err := r.Client.Delete(ctx, res.current)
-				r.logAndRecordOperationResult(ctx, owner, res.current, controllerutil.OperationResult("deleted"), err)
-				if err != nil {
-					return nil, fmt.Errorf("can't delete resource: %w", err)
-				}
+	results := make([]ResourceResult, len(resources))
+	desired := []inventoryEntry{}
+	// reconcileErrs are errors collected using the best effort error policy.
+	reconcileErrs := []error{}
+
+	for _, level := range levels {
+		outcomes := make([]reconcileOutcome, len(level))
+		err := r.parallelize(ctx, len(level), func(j int) {
+			outcomes[j] = r.reconcileBuilderResource(ctx, owner, resources[level[j]], resources)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		// Outcomes are reported in reconciliation order, whatever the order resources were reconciled in.
+		errs := []error{}
+		for j, i := range level {
+			res := resources[i]
+			outcome := outcomes[j]
+			results[i] = outcome.result
+
+			switch outcome.result.OperationResult {
+			case OperationResultSkipped:
+				logger.V(2).Info("Skipping resource due to unsupported by apiserver", "kind", res.gvk.Kind)
+			case OperationResultWaiting:
+				logger.Info("Waiting for dependencies to be ready", "kind", res.gvk.Kind, "name", res.key.Name)
+			default:
+				r.logAndRecordOperationResult(ctx, owner, outcome.result.Object, outcome.result.OperationResult, outcome.operationErr)
+			}
+
+			// Failed resources are kept in the inventory, they may exist.
+			if res.supported && res.builder.Enabled() {
+				desired = append(desired, newInventoryEntry(outcome.result.Object, res.gvk))
 			}
 
+			if outcome.err != nil {
+				results[i].Error = outcome.err
+				errs = append(errs, outcome.err)
+			}
+		}
+
+		if len(errs) == 0 {
 			continue
 		}
 
-		// The build can provide a custom compare function, ensure equality.Semantic knowns it.
-		if comparer, ok := res.builder.(resource.Comparer); ok {
-			err := equality.Semantic.AddFunc(comparer.Equal)
-			if err != nil {
-				return nil, err
+		if !r.bestEffort() {
+			// Following levels may depend on failed resources, stop there.
+			return nil, kerrors.Reduce(kerrors.NewAggregate(errs))
+		}
+
+		for j, i := range level {
+			if outcomes[j].err != nil {
+				reconcileErrs = append(reconcileErrs, r.newObjectError(resources[i], outcomes[j].result.OperationResult, outcomes[j].err))
 			}
 		}
+	}
 
-		// Create case
-		if !res.found && res.builder.Enabled() {
-			res.current = res.builder.Build()
-			err := res.builder.Update(res.current)
-			if err != nil {
+	// Delete resources of disabled builders, dependents first.
+	for i := len(resources) - 1; i >= 0; i-- {
+		res := resources[i]
+		if !res.supported || res.builder.Enabled() {
+			continue
+		}
+
+		results[i].OperationResult = controllerutil.OperationResultNone
+		if !res.found {
+			continue
+		}
+
+		err := r.Client.Delete(ctx, res.current, r.deleteOptions()...)
+		r.logAndRecordOperationResult(ctx, owner, res.current, OperationResultDeleted, err)
+		if err != nil {
+			err = fmt.Errorf("can't delete resource: %w", err)
+			if !r.bestEffort() {
 				return nil, err
 			}
 
-			err = r.Client.Create(ctx, res.current)
-			r.logAndRecordOperationResult(ctx, owner, res.current, controllerutil.OperationResultCreated, err)
+			results[i].OperationResult = OperationResultDeleted
+			results[i].Error = err
+			reconcileErrs = append(reconcileErrs, r.newObjectError(res, OperationResultDeleted, err))
+			continue
+		}
+
+		results[i].OperationResult = OperationResultDeleted
+		if r.DryRun {
+			err = r.setDiff(&results[i], res.current, nil)
 			if err != nil {
 				return nil, err
 			}
 		}
+	}
 
-		// Update case
-		if res.found && res.builder.Enabled() {
-			before := res.current.DeepCopyObject()
-			err := res.builder.Update(res.current)
-			if err != nil {
+	pruned := []ResourceResult{}
+	if r.Prune != nil {
+		pruned, err = r.prune(ctx, owner, desired)
+		if err != nil {
+			if !r.bestEffort() {
 				return nil, err
 			}
 
-			if !equality.Semantic.DeepEqual(before, res.current) {
-				err = r.Client.Update(ctx, res.current)
-				r.logAndRecordOperationResult(ctx, owner, res.current, controllerutil.OperationResultUpdated, err)
-				if err != nil {
-					return nil, err
-				}
+			var aggregate kerrors.Aggregate
+			if errors.As(err, &aggregate) {
+				reconcileErrs = append(reconcileErrs, aggregate.Errors()...)
+			} else {
+				reconcileErrs = append(reconcileErrs, err)
 			}
 		}
+	}
 
-		objects = append(objects, res.current)
+	if len(reconcileErrs) > 0 {
+		return newResult(results, pruned), &ReconcileError{Errors: reconcileErrs}
 	}
 
-	return objects, nil
+	return newResult(results, pruned), nil
 }
 
-func (r *Reconciler) getReconcileResourceFromBuilders(ctx context.Context, builders []resource.Builder) ([]*reconcileResource, error) {
-	logger := log.FromContext(ctx)
+// bestEffort returns whenever errors are handled using the best effort error policy.
+func (r *Reconciler) bestEffort() bool {
+	return r.ErrorPolicy == ErrorPolicyBestEffort
+}
 
-	result := []*reconcileResource{}
+// newObjectError returns an error related to the object of the provided resource.
+func (r *Reconciler) newObjectError(res *reconcileResource, operationResult controllerutil.OperationResult, err error) *ObjectError {
+	return &ObjectError{
+		GVK:             res.gvk,
+		Key:             res.key,
+		OperationResult: operationResult,
+		Err:             err,
+	}
+}
+
+// reconcileOutcome is the outcome of the reconciliation of a builder's resource.
+type reconcileOutcome struct {
+	result ResourceResult
+	// operationErr is the error returned by the api server for the operation reported in the result.
+	operationErr error
+	// err is any error which occurred while reconciling the resource, including operationErr.
+	err error
+}
+
+// reconcileBuilderResource reconciles the provided resource, unless it's not supported by the api server,
+// its builder is disabled or it's waiting for its dependencies.
+// It doesn't log nor record events, so that it can be called concurrently for resources of the same level.
+func (r *Reconciler) reconcileBuilderResource(ctx context.Context, owner client.Object, res *reconcileResource, resources []*reconcileResource) reconcileOutcome {
+	outcome := reconcileOutcome{
+		result: ResourceResult{
+			Object: res.current,
+			GVK:    res.gvk,
+		},
+	}
+	if !res.found {
+		outcome.result.Object = res.build()
+	}
 
-	for _, builder := range builders {
-		res := builder.Build()
-		gvk, err := apiutil.GVKForObject(res, r.Scheme)
+	if res.err != nil {
+		outcome.err = res.err
+		return outcome
+	}
+
+	if !res.supported {
+		outcome.result.OperationResult = OperationResultSkipped
+		return outcome
+	}
+
+	// Disabled builders are handled once all enabled resources are reconciled.
+	if !res.builder.Enabled() {
+		return outcome
+	}
+
+	// Only create a resource once all its dependencies are ready.
+	if !res.found && r.WaitForDependencies {
+		ready, err := r.areDependenciesReady(ctx, res, resources)
 		if err != nil {
-			return nil, err
+			outcome.err = err
+			return outcome
 		}
 
-		object, err := resource.NewObjectFromGVK(gvk, r.Scheme)
+		if !ready {
+			outcome.result.OperationResult = OperationResultWaiting
+			return outcome
+		}
+	}
+
+	var live client.Object
+	if res.found {
+		live = res.current.DeepCopyObject().(client.Object)
+	}
+
+	result, err := r.reconcileResource(ctx, owner, res)
+	outcome.result.Object = res.current
+	outcome.result.OperationResult = result
+	if err != nil {
+		outcome.operationErr = err
+		outcome.err = err
+		return outcome
+	}
+
+	if r.DryRun && result != controllerutil.OperationResultNone {
+		err = r.setDiff(&outcome.result, live, res.current)
 		if err != nil {
-			return nil, fmt.Errorf("can't create new object from %s GVK: %w", gvk, err)
+			outcome.err = err
+			return outcome
+		}
+
+		// The api server may default the changes away, an update without any difference changes nothing.
+		if result == controllerutil.OperationResultUpdated && len(outcome.result.Patch) == 0 {
+			outcome.result.OperationResult = controllerutil.OperationResultNone
 		}
+	}
+
+	// Objects created in dry-run mode don't exist.
+	if r.DryRun && result == controllerutil.OperationResultCreated {
+		outcome.result.Status = resource.NewNotFoundStatus(res.gvk, res.key.Name, res.key.Namespace)
+		return outcome
+	}
 
-		supported, err := r.Discovery.IsGVKSupported(gvk)
+	status, err := r.getStatus(res.current, res.gvk)
+	if err != nil {
+		outcome.err = fmt.Errorf("can't get %s status: %w", res, err)
+		return outcome
+	}
+	outcome.result.Status = status
+
+	return outcome
+}
+
+// reconcileResource creates or updates the provided resource to match its builder's expected state.
+// When the api server returns an error, the attempted operation is returned along with the error.
+func (r *Reconciler) reconcileResource(ctx context.Context, owner client.Object, res *reconcileResource) (controllerutil.OperationResult, error) {
+	if r.ApplyMode == ApplyModeServerSide {
+		return r.apply(ctx, owner, res)
+	}
+
+	// Create case
+	if !res.found {
+		res.current = res.build()
+		err := res.builder.Update(res.current)
 		if err != nil {
-			return nil, fmt.Errorf("can't determine if GVK \"%s\" is supported: %w", gvk.String(), err)
+			return controllerutil.OperationResultNone, err
 		}
 
-		if !supported {
-			logger.V(2).Info("Skipping resource due to unsupported by apiserver", "kind", gvk.Kind)
-			continue
+		err = r.setOwner(owner, res.current)
+		if err != nil {
+			return controllerutil.OperationResultNone, err
 		}
 
-		found := true
-		err = r.Client.Get(ctx, client.ObjectKeyFromObject(res), object, &client.GetOptions{})
+		err = r.Client.Create(ctx, res.current, r.createOptions()...)
 		if err != nil {
-			if apierrors.IsNotFound(err) {
-				found = false
-			} else {
-				return nil, err
-			}
+			return controllerutil.OperationResultCreated, err
 		}
 
-		result = append(result, &reconcileResource{
-			builder: builder,
-			current: object,
-			found:   found,
-		})
+		res.found = true
+		return controllerutil.OperationResultCreated, nil
+	}
+
+	// Update case
+	before := res.current.DeepCopyObject().(client.Object)
+	err := res.builder.Update(res.current)
+	if err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+
+	err = r.setOwner(owner, res.current)
+	if err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+
+	err = r.preserveIgnoredFields(res, before, res.current)
+	if err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+
+	if equal(res.builder, before, res.current) {
+		return controllerutil.OperationResultNone, nil
+	}
+
+	err = r.Client.Update(ctx, res.current, r.updateOptions()...)
+	if err != nil {
+		return controllerutil.OperationResultUpdated, err
+	}
+
+	return controllerutil.OperationResultUpdated, nil
+}
+
+// preserveIgnoredFields sets the ignored fields of the desired object to their value in the current object.
+func (r *Reconciler) preserveIgnoredFields(res *reconcileResource, current, desired client.Object) error {
+	ignored := append([]string{}, r.IgnoredFields[res.gvk.GroupKind()]...)
+	if ignorer, ok := res.builder.(resource.FieldIgnorer); ok {
+		ignored = append(ignored, ignorer.IgnoredFields()...)
+	}
+
+	err := resource.IgnoreFields(current, desired, ignored...)
+	if err != nil {
+		return fmt.Errorf("can't ignore %s fields: %w", res, err)
+	}
+
+	if r.IgnoreServerDefaults {
+		err = resource.PreserveUnsetFields(current, desired, resource.ServerDefaultedFields[res.gvk.GroupKind()]...)
+		if err != nil {
+			return fmt.Errorf("can't preserve %s server defaults: %w", res, err)
+		}
+	}
+
+	return nil
+}
+
+// setDiff sets the difference between the live and desired states of an object on the provided result.
+func (r *Reconciler) setDiff(result *ResourceResult, live, desired client.Object) error {
+	diff, err := r.diffObjects(live, desired)
+	if err != nil {
+		return fmt.Errorf("can't diff %s: %w", result.GVK.Kind, err)
+	}
+
+	result.Diff = diff.diff
+	result.Patch = diff.patch
+	return nil
+}
+
+func (r *Reconciler) createOptions() []client.CreateOption {
+	if r.DryRun {
+		return []client.CreateOption{client.DryRunAll}
+	}
+	return nil
+}
+
+func (r *Reconciler) updateOptions() []client.UpdateOption {
+	if r.DryRun {
+		return []client.UpdateOption{client.DryRunAll}
+	}
+	return nil
+}
+
+func (r *Reconciler) deleteOptions() []client.DeleteOption {
+	if r.DryRun {
+		return []client.DeleteOption{client.DryRunAll}
+	}
+	return nil
+}
+
+// equal compares the current and desired states of a builder's resource, using the builder's comparer if any.
+func equal(builder resource.Builder, current, desired client.Object) bool {
+	if comparer, ok := builder.(resource.Comparer); ok {
+		return comparer.Equal(current, desired)
+	}
+	return equality.Semantic.DeepEqual(current, desired)
+}
+
+// build returns a new object from the resource's builder.
+// The namespace of cluster-scoped objects is cleared, as they can't have one.
+func (res *reconcileResource) build() client.Object {
+	object := res.builder.Build()
+	if !res.namespaced {
+		object.SetNamespace("")
+	}
+	return object
+}
+
+// getReconcileResourceFromBuilders fetches the current state of objects produced by the provided builders.
+// Errors which occurred while fetching an object are set on its resource, see fetchErrors.
+func (r *Reconciler) getReconcileResourceFromBuilders(ctx context.Context, builders []resource.Builder) ([]*reconcileResource, error) {
+	result := make([]*reconcileResource, len(builders))
+	errs := make([]error, len(builders))
+
+	err := r.parallelize(ctx, len(builders), func(i int) {
+		result[i], errs[i] = r.newReconcileResource(builders[i])
+		if errs[i] != nil {
+			return
+		}
+		result[i].err = r.fetchReconcileResource(ctx, result[i])
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = kerrors.Reduce(kerrors.NewAggregate(errs))
+	if err != nil {
+		return nil, err
 	}
 
 	return result, nil
 }
 
+// fetchErrors returns the aggregated errors which occurred while fetching the provided resources.
+func fetchErrors(resources []*reconcileResource) error {
+	errs := []error{}
+	for _, res := range resources {
+		if res.err != nil {
+			errs = append(errs, res.err)
+		}
+	}
+	return kerrors.Reduce(kerrors.NewAggregate(errs))
+}
+
+// parallelize calls work for every piece, running up to the configured concurrency pieces at once.
+// As remaining pieces are skipped once the context is done, the context error is returned
+// when any piece wasn't processed.
+func (r *Reconciler) parallelize(ctx context.Context, pieces int, work func(i int)) error {
+	processed := make([]bool, pieces)
+	workqueue.ParallelizeUntil(ctx, r.concurrency(), pieces, func(i int) {
+		work(i)
+		processed[i] = true
+	})
+
+	for _, ok := range processed {
+		if !ok {
+			return fmt.Errorf("can't reconcile resources: %w", ctx.Err())
+		}
+	}
+
+	return nil
+}
+
+// concurrency returns the maximum number of resources reconciled concurrently.
+func (r *Reconciler) concurrency() int {
+	if r.MaxConcurrency < 1 {
+		return 1
+	}
+	return r.MaxConcurrency
+}
+
+// getReconcileResource fetches the current state of the object produced by the provided builder.
+func (r *Reconciler) getReconcileResource(ctx context.Context, builder resource.Builder) (*reconcileResource, error) {
+	res, err := r.newReconcileResource(builder)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.fetchReconcileResource(ctx, res)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// newReconcileResource returns the resource of the object produced by the provided builder, without fetching it.
+func (r *Reconciler) newReconcileResource(builder resource.Builder) (*reconcileResource, error) {
+	res := builder.Build()
+	gvk, err := apiutil.GVKForObject(res, r.Scheme)
+	if err != nil {
+		return nil, err
+	}
+
+	object, err := resource.NewObjectFromGVK(gvk, r.Scheme)
+	if err != nil {
+		return nil, fmt.Errorf("can't create new object from %s GVK: %w", gvk, err)
+	}
+
+	key := client.ObjectKeyFromObject(res)
+
+	return &reconcileResource{
+		builder:    builder,
+		gvk:        gvk,
+		key:        key,
+		current:    object,
+		supported:  true,
+		namespaced: key.Namespace != "",
+	}, nil
+}
+
+// fetchReconcileResource fetches the current state of the provided resource's object from the api server.
+func (r *Reconciler) fetchReconcileResource(ctx context.Context, res *reconcileResource) error {
+	if r.Discovery != nil {
+		supported, err := r.Discovery.IsGVKSupported(res.gvk)
+		if err != nil {
+			return fmt.Errorf("can't determine if GVK \"%s\" is supported: %w", res.gvk.String(), err)
+		}
+		res.supported = supported
+	}
+
+	if !res.supported {
+		return nil
+	}
+
+	// The scope is only known by the RESTMapper for kinds supported by the api server.
+	namespaced, err := r.Client.IsObjectNamespaced(res.current)
+	if err != nil {
+		return fmt.Errorf("can't determine %s scope: %w", res.gvk.Kind, err)
+	}
+	res.namespaced = namespaced
+
+	if !namespaced {
+		res.key.Namespace = ""
+	}
+
+	err = r.Client.Get(ctx, res.key, res.current, &client.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	res.found = err == nil
+
+	return nil
+}
+
 // logAndRecordOperationResult logs and records an event for the provided object operation result.
 func (r *Reconciler) logAndRecordOperationResult(ctx context.Context, owner, resource runtime.Object, operationResult controllerutil.OperationResult, err error) {
 	logger := log.FromContext(ctx)
@@ -191,7 +653,7 @@ func (r *Reconciler) logAndRecordOperationResult(ctx context.Context, owner, res
 	case controllerutil.OperationResultUpdated, controllerutil.OperationResultUpdatedStatus, controllerutil.OperationResultUpdatedStatusOnly:
 		action = "update"
 		reason = "ResourceUpdate"
-	case controllerutil.OperationResult("deleted"):
+	case OperationResultDeleted:
 		action = "delete"
 		reason = "ResourceDelete"
 	case controllerutil.OperationResultNone:
@@ -200,17 +662,43 @@ func (r *Reconciler) logAndRecordOperationResult(ctx context.Context, owner, res
 		return
 	}
 
+	object := resource.(client.Object)
+	description := r.describeObject(object)
+
+	if r.DryRun {
+		if err == nil {
+			logger.Info(fmt.Sprintf("%sd %s (dry-run)", action, description))
+		} else {
+			logger.Error(err, fmt.Sprintf("failed to %s %s (dry-run)", action, description))
+		}
+		return
+	}
+
 	if err == nil {
-		msg := fmt.Sprintf("%sd resource %s of type %T", action, resource.(metav1.Object).GetName(), resource.(metav1.Object))
+		msg := fmt.Sprintf("%sd %s", action, description)
 		reason := fmt.Sprintf("%sSuccess", reason)
 		logger.Info(msg)
 		r.Recorder.Event(owner, corev1.EventTypeNormal, reason, msg)
 	}
 
 	if err != nil {
-		msg := fmt.Sprintf("failed to %s resource %s of Type %T", action, resource.(metav1.Object).GetName(), resource.(metav1.Object))
+		msg := fmt.Sprintf("failed to %s %s", action, description)
 		reason := fmt.Sprintf("%sError", reason)
 		logger.Error(err, msg)
 		r.Recorder.Event(owner, corev1.EventTypeWarning, reason, msg)
 	}
 }
+
+// describeObject returns a human readable description of the provided object, made of its kind and name.
+// The name is prefixed by the namespace for namespaced objects.
+func (r *Reconciler) describeObject(object client.Object) string {
+	kind := fmt.Sprintf("%T", object)
+	if gvk, err := apiutil.GVKForObject(object, r.Scheme); err == nil {
+		kind = gvk.Kind
+	}
+
+	if object.GetNamespace() == "" {
+		return fmt.Sprintf("%s %s", kind, object.GetName())
+	}
+	return fmt.Sprintf("%s %s/%s", kind, object.GetNamespace(), object.GetName())
+}
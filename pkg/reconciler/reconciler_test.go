@@ -19,18 +19,28 @@ package reconciler_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/rand"
 
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/client-go/tools/record"
 
+	resourcebuilders "github.com/alexandrevilain/controller-tools/pkg/builders"
 	"github.com/alexandrevilain/controller-tools/pkg/discovery"
 	"github.com/alexandrevilain/controller-tools/pkg/fake"
+	"github.com/alexandrevilain/controller-tools/pkg/finalizer"
 	"github.com/alexandrevilain/controller-tools/pkg/reconciler"
 	"github.com/alexandrevilain/controller-tools/pkg/resource"
+	kstatus "sigs.k8s.io/cli-utils/pkg/kstatus/status"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -71,12 +81,18 @@ var _ = Describe("Reconciler", func() {
 		})
 
 		It("creates a new object if one doesn't exists", func() {
-			objects, err := rec.ReconcileBuilders(context.TODO(), owner, builders)
+			result, err := rec.ReconcileBuilders(context.TODO(), owner, builders)
 
 			By("returning no error")
 			Expect(err).NotTo(HaveOccurred())
 			By("returning objects")
-			Expect(objects).To(HaveLen(1))
+			Expect(result.Objects()).To(HaveLen(1))
+			Expect(result.Resources[0].OperationResult).To(Equal(controllerutil.OperationResultCreated))
+
+			By("returning the object status")
+			Expect(result.Resources[0].Status).NotTo(BeNil())
+			Expect(result.Ready).To(BeFalse())
+			Expect(result.RequeueAfter).To(Equal(reconciler.DefaultRequeueAfter))
 
 			By("actually having the deployment created")
 			fetched := &appsv1.Deployment{}
@@ -90,11 +106,11 @@ var _ = Describe("Reconciler", func() {
 
 		It("updates existing object", func() {
 			var scale int32 = 2
-			objects, err := rec.ReconcileBuilders(context.TODO(), owner, builders)
+			result, err := rec.ReconcileBuilders(context.TODO(), owner, builders)
 			By("returning no error")
 			Expect(err).NotTo(HaveOccurred())
 			By("returning objects")
-			Expect(objects).To(HaveLen(1))
+			Expect(result.Objects()).To(HaveLen(1))
 
 			fakeDepBuilder := builders[0].(*fake.DeploymentBuilder)
 			fakeDepBuilder.MutateObject = func(o client.Object) {
@@ -102,16 +118,816 @@ var _ = Describe("Reconciler", func() {
 				deploy.Spec.Replicas = &scale
 			}
 
-			objects, err = rec.ReconcileBuilders(context.TODO(), owner, builders)
+			result, err = rec.ReconcileBuilders(context.TODO(), owner, builders)
 			By("returning no error")
 			Expect(err).NotTo(HaveOccurred())
 			By("returning objects")
-			Expect(objects).To(HaveLen(1))
+			Expect(result.Objects()).To(HaveLen(1))
+			Expect(result.Resources[0].OperationResult).To(Equal(controllerutil.OperationResultUpdated))
 
 			By("actually having the deployment scaled")
 			fetched := &appsv1.Deployment{}
 			Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(deploy), fetched)).To(Succeed())
 			Expect(*fetched.Spec.Replicas).To(Equal(scale))
 		})
+
+		It("uses the builder's comparer", func() {
+			var scale int32 = 4
+			comparing := &fake.ComparingDeploymentBuilder{
+				DeploymentBuilder: builders[0].(*fake.DeploymentBuilder),
+				EqualFunc: func(current, desired client.Object) bool {
+					// Only containers are compared, replicas and server defaults are ignored.
+					currentSpec := current.(*appsv1.Deployment).Spec.Template.Spec
+					desiredSpec := desired.(*appsv1.Deployment).Spec.Template.Spec
+					return currentSpec.Containers[0].Image == desiredSpec.Containers[0].Image
+				},
+			}
+			builders = []resource.Builder{comparing}
+
+			_, err := rec.ReconcileBuilders(context.TODO(), owner, builders)
+			Expect(err).NotTo(HaveOccurred())
+
+			comparing.MutateObject = func(o client.Object) {
+				o.(*appsv1.Deployment).Spec.Replicas = &scale
+			}
+
+			result, err := rec.ReconcileBuilders(context.TODO(), owner, builders)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Resources[0].OperationResult).To(Equal(controllerutil.OperationResultNone))
+
+			fetched := &appsv1.Deployment{}
+			Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(deploy), fetched)).To(Succeed())
+			Expect(*fetched.Spec.Replicas).NotTo(Equal(scale))
+		})
+
+		It("doesn't update objects because of server defaults", func() {
+			rec.IgnoreServerDefaults = true
+
+			_, err := rec.ReconcileBuilders(context.TODO(), owner, builders)
+			Expect(err).NotTo(HaveOccurred())
+
+			result, err := rec.ReconcileBuilders(context.TODO(), owner, builders)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Resources[0].OperationResult).To(Equal(controllerutil.OperationResultNone))
+		})
+
+		It("doesn't update NodePort services because of server defaults", func() {
+			rec.IgnoreServerDefaults = true
+			name := fmt.Sprintf("%s-server-defaults", deploy.Name)
+			builder := resourcebuilders.NewService(name, "default",
+				resourcebuilders.Mutate(func(service *corev1.Service) error {
+					service.Spec = corev1.ServiceSpec{
+						Type:     corev1.ServiceTypeNodePort,
+						Selector: map[string]string{"app": name},
+						Ports:    []corev1.ServicePort{{Name: "http", Port: 80}},
+					}
+					return nil
+				}),
+			)
+
+			_, err := rec.ReconcileBuilders(context.TODO(), owner, []resource.Builder{builder})
+			Expect(err).NotTo(HaveOccurred())
+
+			result, err := rec.ReconcileBuilders(context.TODO(), owner, []resource.Builder{builder})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Resources[0].OperationResult).To(Equal(controllerutil.OperationResultNone))
+		})
+
+		It("keeps the live value of ignored fields", func() {
+			var scale int32 = 5
+			rec.IgnoreServerDefaults = true
+			rec.IgnoredFields = map[schema.GroupKind][]string{
+				appsv1.SchemeGroupVersion.WithKind("Deployment").GroupKind(): {"spec.replicas"},
+			}
+			fakeDepBuilder := builders[0].(*fake.DeploymentBuilder)
+			fakeDepBuilder.MutateObject = func(o client.Object) {
+				var replicas int32 = 1
+				o.(*appsv1.Deployment).Spec.Replicas = &replicas
+			}
+
+			_, err := rec.ReconcileBuilders(context.TODO(), owner, builders)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("scaling the deployment outside of the builder")
+			fetched := &appsv1.Deployment{}
+			Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(deploy), fetched)).To(Succeed())
+			fetched.Spec.Replicas = &scale
+			Expect(c.Update(context.TODO(), fetched)).To(Succeed())
+
+			result, err := rec.ReconcileBuilders(context.TODO(), owner, builders)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Resources[0].OperationResult).To(Equal(controllerutil.OperationResultNone))
+
+			Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(deploy), fetched)).To(Succeed())
+			Expect(*fetched.Spec.Replicas).To(Equal(scale))
+		})
+
+		Context("with server-side apply", func() {
+			var recorder *record.FakeRecorder
+
+			BeforeEach(func() {
+				recorder = record.NewFakeRecorder(512)
+				rec.Recorder = recorder
+				rec.ApplyMode = reconciler.ApplyModeServerSide
+				rec.FieldManager = "controller-tools-test"
+			})
+
+			It("applies objects and only reports changes", func() {
+				var scale int32 = 3
+
+				result, err := rec.ReconcileBuilders(context.TODO(), owner, builders)
+				By("returning no error")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Objects()).To(HaveLen(1))
+				Expect(recorder.Events).To(Receive(ContainSubstring("RessourceCreateSuccess")))
+
+				By("not reporting an update when nothing changed")
+				result, err = rec.ReconcileBuilders(context.TODO(), owner, builders)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Resources[0].OperationResult).To(Equal(controllerutil.OperationResultNone))
+				Expect(recorder.Events).NotTo(Receive())
+
+				By("reporting an update when a field changed")
+				fakeDepBuilder := builders[0].(*fake.DeploymentBuilder)
+				fakeDepBuilder.MutateObject = func(o client.Object) {
+					deploy := o.(*appsv1.Deployment)
+					deploy.Spec.Replicas = &scale
+				}
+				_, err = rec.ReconcileBuilders(context.TODO(), owner, builders)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(recorder.Events).To(Receive(ContainSubstring("ResourceUpdateSuccess")))
+
+				fetched := &appsv1.Deployment{}
+				Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(deploy), fetched)).To(Succeed())
+				Expect(*fetched.Spec.Replicas).To(Equal(scale))
+
+				By("using the configured field manager")
+				managers := []string{}
+				for _, entry := range fetched.GetManagedFields() {
+					managers = append(managers, entry.Manager)
+				}
+				Expect(managers).To(ContainElement("controller-tools-test"))
+			})
+
+			It("doesn't report unchanged objects in dry-run mode", func() {
+				_, err := rec.ReconcileBuilders(context.TODO(), owner, builders)
+				Expect(err).NotTo(HaveOccurred())
+
+				rec.DryRun = true
+				result, err := rec.ReconcileBuilders(context.TODO(), owner, builders)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Resources[0].OperationResult).To(Equal(controllerutil.OperationResultNone))
+				Expect(result.Resources[0].Diff).To(BeEmpty())
+				Expect(result.Resources[0].Patch).To(BeEmpty())
+			})
+		})
+
+		Context("with concurrency", func() {
+			var recorder *record.FakeRecorder
+
+			BeforeEach(func() {
+				recorder = record.NewFakeRecorder(512)
+				rec.Recorder = recorder
+				rec.MaxConcurrency = 4
+			})
+
+			It("reconciles all resources and reports them in order", func() {
+				names := []string{deploy.Name}
+				for i := 0; i < 7; i++ {
+					builder := fake.NewDeploymentBuilder(fmt.Sprintf("%s-%d", deploy.Name, i), "default")
+					if i%2 == 1 {
+						// Odd builders depend on the previous one.
+						builder.DependsOn = []resource.Dependency{{Object: &appsv1.Deployment{}, Name: names[len(names)-1], Namespace: "default"}}
+					}
+					builders = append(builders, builder)
+					names = append(names, builder.Name)
+				}
+
+				result, err := rec.ReconcileBuilders(context.TODO(), owner, builders)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Objects()).To(HaveLen(len(names)))
+
+				for _, res := range result.Resources {
+					Expect(res.OperationResult).To(Equal(controllerutil.OperationResultCreated))
+					Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(res.Object), &appsv1.Deployment{})).To(Succeed())
+				}
+
+				By("reporting events level by level, in reconciliation order")
+				expectedOrder := []string{names[0], names[1], names[3], names[5], names[7], names[2], names[4], names[6]}
+				for _, name := range expectedOrder {
+					Expect(recorder.Events).To(Receive(HaveSuffix("created Deployment default/" + name)))
+				}
+			})
+
+			It("aggregates errors of resources reconciled concurrently", func() {
+				builders = append(builders,
+					fake.NewDeploymentBuilder(fmt.Sprintf("%s-a", deploy.Name), "missing-namespace"),
+					fake.NewDeploymentBuilder(fmt.Sprintf("%s-b", deploy.Name), "missing-namespace"),
+				)
+
+				_, err := rec.ReconcileBuilders(context.TODO(), owner, builders)
+				Expect(err).To(HaveOccurred())
+
+				var aggregate kerrors.Aggregate
+				Expect(errors.As(err, &aggregate)).To(BeTrue())
+				Expect(aggregate.Errors()).To(HaveLen(2))
+
+				By("still reconciling other resources of the same level")
+				Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(deploy), &appsv1.Deployment{})).To(Succeed())
+			})
+
+			It("returns the context error when the context is done", func() {
+				ctx, cancel := context.WithCancel(context.TODO())
+				cancel()
+
+				_, err := rec.ReconcileBuilders(ctx, owner, builders)
+				Expect(err).To(MatchError(context.Canceled))
+
+				_, err = rec.CleanupBuilders(ctx, owner, builders)
+				Expect(err).To(MatchError(context.Canceled))
+			})
+
+			It("returns the context error when the context is done while reconciling resources", func() {
+				rec.MaxConcurrency = 1
+				ctx, cancel := context.WithCancel(context.TODO())
+				defer cancel()
+
+				builders = []resource.Builder{
+					&cancelingBuilder{DeploymentBuilder: builders[0].(*fake.DeploymentBuilder), cancel: cancel},
+					fake.NewDeploymentBuilder(fmt.Sprintf("%s-skipped", deploy.Name), "default"),
+				}
+
+				_, err := rec.ReconcileBuilders(ctx, owner, builders)
+				Expect(err).To(MatchError(context.Canceled))
+			})
+		})
+
+		Context("with the best effort error policy", func() {
+			BeforeEach(func() {
+				rec.ErrorPolicy = reconciler.ErrorPolicyBestEffort
+			})
+
+			It("reconciles all resources and returns per-object errors", func() {
+				failing := fake.NewDeploymentBuilder(fmt.Sprintf("%s-failing", deploy.Name), "missing-namespace")
+				builders = append([]resource.Builder{failing}, builders...)
+
+				result, err := rec.ReconcileBuilders(context.TODO(), owner, builders)
+				Expect(err).To(HaveOccurred())
+				Expect(result).NotTo(BeNil())
+				Expect(result.Ready).To(BeFalse())
+
+				By("returning successfully reconciled objects")
+				Expect(result.Objects()).To(HaveLen(1))
+				Expect(result.Objects()[0].GetName()).To(Equal(deploy.Name))
+				Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(deploy), &appsv1.Deployment{})).To(Succeed())
+
+				By("returning errors callers can inspect")
+				var reconcileErr *reconciler.ReconcileError
+				Expect(errors.As(err, &reconcileErr)).To(BeTrue())
+				Expect(reconcileErr.ObjectErrors()).To(HaveLen(1))
+
+				var objectErr *reconciler.ObjectError
+				Expect(errors.As(err, &objectErr)).To(BeTrue())
+				Expect(objectErr.Key).To(Equal(client.ObjectKey{Namespace: "missing-namespace", Name: failing.Name}))
+				Expect(objectErr.GVK.Kind).To(Equal("Deployment"))
+				Expect(objectErr.OperationResult).To(Equal(controllerutil.OperationResultCreated))
+				Expect(apierrors.IsNotFound(objectErr)).To(BeTrue())
+			})
+
+			It("reconciles all resources when some can't be fetched", func() {
+				unreadable := fake.NewDeploymentBuilder(fmt.Sprintf("%s-unreadable", deploy.Name), "default")
+				builders = append([]resource.Builder{unreadable}, builders...)
+				rec.Client = &failingClient{Client: c, name: unreadable.Name}
+
+				result, err := rec.ReconcileBuilders(context.TODO(), owner, builders)
+				Expect(err).To(HaveOccurred())
+				Expect(result).NotTo(BeNil())
+				Expect(result.Resources[0].Error).To(HaveOccurred())
+				Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(deploy), &appsv1.Deployment{})).To(Succeed())
+
+				var reconcileErr *reconciler.ReconcileError
+				Expect(errors.As(err, &reconcileErr)).To(BeTrue())
+				Expect(reconcileErr.ObjectErrors()).To(HaveLen(1))
+				Expect(reconcileErr.ObjectErrors()[0].Key).To(Equal(client.ObjectKey{Namespace: "default", Name: unreadable.Name}))
+				Expect(reconcileErr.ObjectErrors()[0].OperationResult).To(BeEmpty())
+				Expect(apierrors.IsInternalError(reconcileErr.ObjectErrors()[0])).To(BeTrue())
+			})
+		})
+
+		Context("with typed builders", func() {
+			It("reconciles resources of adapted builders", func() {
+				configMapBuilder := fake.NewConfigMapBuilder(fmt.Sprintf("%s-config", deploy.Name), "default")
+				configMapBuilder.Data = map[string]string{"key": "value"}
+				configMapBuilder.DependsOn = []resource.Dependency{{Object: &appsv1.Deployment{}, Name: deploy.Name, Namespace: "default"}}
+				builders = append(builders, resource.NewBuilder[*corev1.ConfigMap](configMapBuilder))
+
+				result, err := rec.ReconcileBuilders(context.TODO(), owner, builders)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Resources).To(HaveLen(2))
+				Expect(result.Resources[1].Object.GetName()).To(Equal(configMapBuilder.Name))
+
+				configMap := &corev1.ConfigMap{}
+				Expect(c.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: configMapBuilder.Name}, configMap)).To(Succeed())
+				Expect(configMap.Data).To(Equal(configMapBuilder.Data))
+			})
+
+			It("returns an error when a builder is called with an unexpected object type", func() {
+				builders = append(builders, &mismatchingBuilder{fake.NewDeploymentBuilder(fmt.Sprintf("%s-mismatch", deploy.Name), "default")})
+
+				_, err := rec.ReconcileBuilders(context.TODO(), owner, builders)
+				var mismatchErr *resource.TypeMismatchError
+				Expect(errors.As(err, &mismatchErr)).To(BeTrue())
+			})
+		})
+
+		Context("with builders of the builders package", func() {
+			It("doesn't update objects once reconciled", func() {
+				name := fmt.Sprintf("%s-builders", deploy.Name)
+				builders = []resource.Builder{
+					resourcebuilders.NewServiceAccount(name, "default", resourcebuilders.WithLabels{"app": name}),
+					resourcebuilders.NewConfigMap(name, "default", resourcebuilders.WithData{"key": "value"}),
+					resourcebuilders.NewService(name, "default",
+						resourcebuilders.WithSelector{"app": name},
+						resourcebuilders.WithPorts{{Name: "http", Port: 80}},
+					),
+					resourcebuilders.NewDeployment(name, "default",
+						resourcebuilders.WithSelector{"app": name},
+						resourcebuilders.WithPodTemplate{
+							ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": name}},
+							Spec: corev1.PodSpec{
+								Containers: []corev1.Container{{Name: "app", Image: "nginx"}},
+							},
+						},
+						resourcebuilders.WithIgnoredFields{"spec.replicas"},
+					),
+					resourcebuilders.NewHorizontalPodAutoscaler(name, "default",
+						resourcebuilders.WithScaleTargetRef{APIVersion: "apps/v1", Kind: "Deployment", Name: name},
+						resourcebuilders.WithMaxReplicas(3),
+					),
+				}
+
+				result, err := rec.ReconcileBuilders(context.TODO(), owner, builders)
+				Expect(err).NotTo(HaveOccurred())
+				for _, res := range result.Resources {
+					Expect(res.OperationResult).To(Equal(controllerutil.OperationResultCreated))
+				}
+
+				result, err = rec.ReconcileBuilders(context.TODO(), owner, builders)
+				Expect(err).NotTo(HaveOccurred())
+				for _, res := range result.Resources {
+					Expect(res.OperationResult).To(Equal(controllerutil.OperationResultNone), res.GVK.Kind)
+				}
+			})
+
+			It("doesn't update NodePort services once reconciled", func() {
+				name := fmt.Sprintf("%s-nodeport", deploy.Name)
+				builder := resourcebuilders.NewService(name, "default",
+					resourcebuilders.WithServiceType(corev1.ServiceTypeNodePort),
+					resourcebuilders.WithSelector{"app": name},
+					resourcebuilders.WithPorts{{Name: "http", Port: 80}},
+				)
+
+				result, err := rec.ReconcileBuilders(context.TODO(), owner, []resource.Builder{builder})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Resources[0].OperationResult).To(Equal(controllerutil.OperationResultCreated))
+
+				result, err = rec.ReconcileBuilders(context.TODO(), owner, []resource.Builder{builder})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Resources[0].OperationResult).To(Equal(controllerutil.OperationResultNone))
+			})
+		})
+
+		Context("in dry-run mode", func() {
+			BeforeEach(func() {
+				rec.DryRun = true
+			})
+
+			It("reports creations without creating objects", func() {
+				result, err := rec.ReconcileBuilders(context.TODO(), owner, builders)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Resources[0].OperationResult).To(Equal(controllerutil.OperationResultCreated))
+				Expect(result.Resources[0].Diff).To(ContainSubstring("+++ desired/Deployment default/" + deploy.Name))
+				Expect(result.Resources[0].Diff).To(ContainSubstring("+  name: " + deploy.Name))
+				Expect(result.Resources[0].Patch).NotTo(BeEmpty())
+				Expect(result.Resources[0].Status.State).To(Equal(kstatus.NotFoundStatus))
+				Expect(result.Ready).To(BeFalse())
+
+				err = c.Get(context.TODO(), client.ObjectKeyFromObject(deploy), &appsv1.Deployment{})
+				Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			})
+
+			It("doesn't report updates defaulted away by the api server", func() {
+				rec.DryRun = false
+				_, err := rec.ReconcileBuilders(context.TODO(), owner, builders)
+				Expect(err).NotTo(HaveOccurred())
+
+				rec.DryRun = true
+				builders[0].(*fake.DeploymentBuilder).MutateObject = func(o client.Object) {
+					o.(*appsv1.Deployment).Spec.Replicas = nil
+				}
+				result, err := rec.ReconcileBuilders(context.TODO(), owner, builders)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Resources[0].OperationResult).To(Equal(controllerutil.OperationResultNone))
+				Expect(result.Resources[0].Diff).To(BeEmpty())
+				Expect(result.Resources[0].Patch).To(BeEmpty())
+			})
+
+			It("doesn't write objects reconciled by ReconcileBuilder", func() {
+				_, err := rec.ReconcileBuilder(context.TODO(), owner, builders[0])
+				Expect(err).NotTo(HaveOccurred())
+
+				err = c.Get(context.TODO(), client.ObjectKeyFromObject(deploy), &appsv1.Deployment{})
+				Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+				rec.DryRun = false
+				_, err = rec.ReconcileBuilder(context.TODO(), owner, builders[0])
+				Expect(err).NotTo(HaveOccurred())
+
+				rec.DryRun = true
+				var scale int32 = 2
+				builders[0].(*fake.DeploymentBuilder).MutateObject = func(o client.Object) {
+					o.(*appsv1.Deployment).Spec.Replicas = &scale
+				}
+				_, err = rec.ReconcileBuilder(context.TODO(), owner, builders[0])
+				Expect(err).NotTo(HaveOccurred())
+
+				fetched := &appsv1.Deployment{}
+				Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(deploy), fetched)).To(Succeed())
+				Expect(*fetched.Spec.Replicas).To(Equal(int32(1)))
+			})
+
+			It("reports updates without updating objects", func() {
+				var scale int32 = 2
+
+				rec.DryRun = false
+				_, err := rec.ReconcileBuilders(context.TODO(), owner, builders)
+				Expect(err).NotTo(HaveOccurred())
+
+				rec.DryRun = true
+				builders[0].(*fake.DeploymentBuilder).MutateObject = func(o client.Object) {
+					o.(*appsv1.Deployment).Spec.Replicas = &scale
+				}
+
+				result, err := rec.ReconcileBuilders(context.TODO(), owner, builders)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Resources[0].OperationResult).To(Equal(controllerutil.OperationResultUpdated))
+				Expect(result.Resources[0].Diff).To(ContainSubstring("-  replicas: 1"))
+				Expect(result.Resources[0].Diff).To(ContainSubstring("+  replicas: 2"))
+				Expect(result.Resources[0].Patch).To(ContainElement(HaveField("Path", "/spec/replicas")))
+
+				fetched := &appsv1.Deployment{}
+				Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(deploy), fetched)).To(Succeed())
+				Expect(*fetched.Spec.Replicas).To(Equal(int32(1)))
+			})
+
+			It("reports pruned resources without deleting them", func() {
+				owner = createOwner()
+				orphan := fake.NewDeploymentBuilder(fmt.Sprintf("orphan-%d", rand.Int31()), "default") //nolint:gosec
+				rec.Prune = &reconciler.PruneOptions{}
+
+				rec.DryRun = false
+				_, err := rec.ReconcileBuilders(context.TODO(), owner, append(builders, orphan))
+				Expect(err).NotTo(HaveOccurred())
+
+				rec.DryRun = true
+				result, err := rec.ReconcileBuilders(context.TODO(), owner, builders)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Pruned).To(HaveLen(1))
+				Expect(result.Pruned[0].OperationResult).To(Equal(reconciler.OperationResultDeleted))
+				Expect(result.Pruned[0].Diff).To(ContainSubstring("-  name: " + orphan.Name))
+
+				Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(orphan.Build()), &appsv1.Deployment{})).To(Succeed())
+
+				By("keeping the resource in the inventory")
+				rec.DryRun = false
+				result, err = rec.ReconcileBuilders(context.TODO(), owner, builders)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Pruned).To(HaveLen(1))
+				Expect(result.Pruned[0].Diff).To(BeEmpty())
+
+				err = c.Get(context.TODO(), client.ObjectKeyFromObject(orphan.Build()), &appsv1.Deployment{})
+				Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			})
+		})
+
+		Context("with an owner reference policy", func() {
+			BeforeEach(func() {
+				owner = createOwner()
+			})
+
+			It("sets the owner as controller reference", func() {
+				rec.OwnerReferencePolicy = reconciler.OwnerReferencePolicyController
+
+				_, err := rec.ReconcileBuilders(context.TODO(), owner, builders)
+				Expect(err).NotTo(HaveOccurred())
+
+				fetched := &appsv1.Deployment{}
+				Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(deploy), fetched)).To(Succeed())
+				Expect(metav1.IsControlledBy(fetched, owner)).To(BeTrue())
+			})
+
+			It("tracks objects in another namespace using labels", func() {
+				rec.OwnerReferencePolicy = reconciler.OwnerReferencePolicyController
+				builders[0].(*fake.DeploymentBuilder).Namespace = "kube-public"
+
+				_, err := rec.ReconcileBuilders(context.TODO(), owner, builders)
+				Expect(err).NotTo(HaveOccurred())
+
+				fetched := &appsv1.Deployment{}
+				Expect(c.Get(context.TODO(), client.ObjectKey{Name: deploy.Name, Namespace: "kube-public"}, fetched)).To(Succeed())
+				Expect(fetched.GetOwnerReferences()).To(BeEmpty())
+				Expect(fetched.GetLabels()).To(HaveKeyWithValue(reconciler.OwnerUIDLabel, string(owner.GetUID())))
+				Expect(fetched.GetAnnotations()).To(HaveKeyWithValue(reconciler.OwnerNameAnnotation, owner.GetName()))
+			})
+		})
+
+		Context("with cluster-scoped resources", func() {
+			var role *fake.ClusterRoleBuilder
+			var recorder *record.FakeRecorder
+
+			BeforeEach(func() {
+				owner = createOwner()
+				recorder = record.NewFakeRecorder(512)
+				rec.Recorder = recorder
+				rec.OwnerReferencePolicy = reconciler.OwnerReferencePolicyController
+				role = fake.NewClusterRoleBuilder(fmt.Sprintf("role-%d", rand.Int31())) //nolint:gosec
+				builders = []resource.Builder{role}
+			})
+
+			It("tracks them using labels and deletes them with their owner", func() {
+				result, err := rec.ReconcileBuilders(context.TODO(), owner, builders)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Resources[0].OperationResult).To(Equal(controllerutil.OperationResultCreated))
+				Expect(recorder.Events).To(Receive(ContainSubstring("created ClusterRole " + role.Name)))
+
+				fetched := &rbacv1.ClusterRole{}
+				Expect(c.Get(context.TODO(), client.ObjectKey{Name: role.Name}, fetched)).To(Succeed())
+				Expect(fetched.GetOwnerReferences()).To(BeEmpty())
+				Expect(fetched.GetLabels()).To(HaveKeyWithValue(reconciler.OwnerUIDLabel, string(owner.GetUID())))
+				Expect(fetched.GetAnnotations()).To(HaveKeyWithValue(reconciler.OwnerNamespaceAnnotation, owner.GetNamespace()))
+
+				By("keeping them when tracked by another owner")
+				done, err := rec.CleanupBuilders(context.TODO(), createOwner(), builders)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(done).To(BeTrue())
+				Expect(c.Get(context.TODO(), client.ObjectKey{Name: role.Name}, fetched)).To(Succeed())
+
+				By("deleting them with their owner")
+				done, err = rec.CleanupBuilders(context.TODO(), owner, builders)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(done).To(BeFalse())
+				err = c.Get(context.TODO(), client.ObjectKey{Name: role.Name}, fetched)
+				Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+				done, err = rec.CleanupBuilders(context.TODO(), owner, builders)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(done).To(BeTrue())
+			})
+		})
+
+		Context("with a cleanup finalizer", func() {
+			var f *finalizer.Finalizer
+
+			BeforeEach(func() {
+				owner = createOwner()
+				rec.OwnerReferencePolicy = reconciler.OwnerReferencePolicyController
+				builders[0].(*fake.DeploymentBuilder).Namespace = "kube-public"
+				builders = append(builders, fake.NewDeploymentBuilder(fmt.Sprintf("referenced-%d", rand.Int31()), "default")) //nolint:gosec
+
+				f = finalizer.New("test.controller-tools.alexandrevilain.dev/cleanup", c)
+				Expect(f.Register("builders", rec.CleanupHandler(func(client.Object) []resource.Builder {
+					return builders
+				}))).To(Succeed())
+			})
+
+			It("deletes resources without owner reference before removing the finalizer", func() {
+				result, err := f.Reconcile(context.TODO(), owner)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Deleting).To(BeFalse())
+				Expect(owner.GetFinalizers()).To(ContainElement(f.Name()))
+
+				_, err = rec.ReconcileBuilders(context.TODO(), owner, builders)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(c.Delete(context.TODO(), owner)).To(Succeed())
+				Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(owner), owner)).To(Succeed())
+
+				By("deleting the resource tracked using labels")
+				result, err = f.Reconcile(context.TODO(), owner)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Deleting).To(BeTrue())
+				Expect(result.RequeueAfter).To(Equal(reconciler.DefaultRequeueAfter))
+
+				err = c.Get(context.TODO(), client.ObjectKey{Name: deploy.Name, Namespace: "kube-public"}, &appsv1.Deployment{})
+				Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+				By("keeping the owner referenced resource for the garbage collector")
+				referenced := builders[1].Build()
+				Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(referenced), &appsv1.Deployment{})).To(Succeed())
+
+				By("removing the finalizer once resources are gone")
+				result, err = f.Reconcile(context.TODO(), owner)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.RequeueAfter).To(BeZero())
+
+				err = c.Get(context.TODO(), client.ObjectKeyFromObject(owner), &appsv1.Deployment{})
+				Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			})
+		})
+
+		Context("with pruning enabled", func() {
+			var orphan *fake.DeploymentBuilder
+
+			BeforeEach(func() {
+				owner = createOwner()
+				orphan = fake.NewDeploymentBuilder(fmt.Sprintf("orphan-%d", rand.Int31()), "default") //nolint:gosec
+				builders = append(builders, orphan)
+				rec.Prune = &reconciler.PruneOptions{}
+			})
+
+			It("deletes resources no longer produced by builders", func() {
+				_, err := rec.ReconcileBuilders(context.TODO(), owner, builders)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(orphan.Build()), &appsv1.Deployment{})).To(Succeed())
+
+				_, err = rec.ReconcileBuilders(context.TODO(), owner, builders[:1])
+				Expect(err).NotTo(HaveOccurred())
+
+				By("deleting the orphaned resource")
+				err = c.Get(context.TODO(), client.ObjectKeyFromObject(orphan.Build()), &appsv1.Deployment{})
+				Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+				By("keeping resources still produced by builders")
+				Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(deploy), &appsv1.Deployment{})).To(Succeed())
+			})
+
+			It("doesn't delete resources in dry-run mode", func() {
+				rec.Prune.DryRun = true
+
+				_, err := rec.ReconcileBuilders(context.TODO(), owner, builders)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = rec.ReconcileBuilders(context.TODO(), owner, builders[:1])
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(orphan.Build()), &appsv1.Deployment{})).To(Succeed())
+			})
+
+			It("doesn't delete skipped kinds", func() {
+				rec.Prune.Skip = []schema.GroupKind{appsv1.SchemeGroupVersion.WithKind("Deployment").GroupKind()}
+
+				_, err := rec.ReconcileBuilders(context.TODO(), owner, builders)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = rec.ReconcileBuilders(context.TODO(), owner, builders[:1])
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(orphan.Build()), &appsv1.Deployment{})).To(Succeed())
+			})
+
+			It("returns per-object errors of resources which can't be pruned", func() {
+				_, err := rec.ReconcileBuilders(context.TODO(), owner, builders)
+				Expect(err).NotTo(HaveOccurred())
+
+				rec.ErrorPolicy = reconciler.ErrorPolicyBestEffort
+				rec.Client = &failingClient{Client: c, name: orphan.Name}
+				_, err = rec.ReconcileBuilders(context.TODO(), owner, builders[:1])
+				Expect(err).To(HaveOccurred())
+
+				var reconcileErr *reconciler.ReconcileError
+				Expect(errors.As(err, &reconcileErr)).To(BeTrue())
+				Expect(reconcileErr.ObjectErrors()).To(HaveLen(1))
+				Expect(reconcileErr.ObjectErrors()[0].Key).To(Equal(client.ObjectKey{Namespace: "default", Name: orphan.Name}))
+				Expect(reconcileErr.ObjectErrors()[0].GVK.Kind).To(Equal("Deployment"))
+				Expect(reconcileErr.ObjectErrors()[0].OperationResult).To(Equal(reconciler.OperationResultDeleted))
+
+				Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(orphan.Build()), &appsv1.Deployment{})).To(Succeed())
+			})
+		})
 	})
 })
+
+var _ = Describe("Reconciler", func() {
+	Describe("Dependencies", func() {
+		var builders []resource.Builder
+		var rec *reconciler.Reconciler
+		var dependent *fake.DeploymentBuilder
+		var configMap *corev1.ConfigMap
+
+		BeforeEach(func() {
+			configMap = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      fmt.Sprintf("config-%d", rand.Int31()), //nolint:gosec
+					Namespace: "default",
+				},
+			}
+
+			dependent = fake.NewDeploymentBuilder(fmt.Sprintf("deploy-%d", rand.Int31()), "default") //nolint:gosec
+			dependent.DependsOn = []resource.Dependency{
+				{
+					Object:    &corev1.ConfigMap{},
+					Name:      configMap.Name,
+					Namespace: configMap.Namespace,
+				},
+			}
+			builders = []resource.Builder{dependent}
+
+			discoveryManager, err := discovery.NewManager(cfg, c.Scheme())
+			Expect(err).ToNot(HaveOccurred())
+
+			rec = &reconciler.Reconciler{
+				Client:              c,
+				Scheme:              c.Scheme(),
+				Recorder:            record.NewFakeRecorder(512),
+				Discovery:           discoveryManager,
+				WaitForDependencies: true,
+			}
+		})
+
+		It("waits for dependencies before creating dependents", func() {
+			result, err := rec.ReconcileBuilders(context.TODO(), createOwner(), builders)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("reporting the dependent as waiting")
+			Expect(result.Resources[0].OperationResult).To(Equal(reconciler.OperationResultWaiting))
+			Expect(result.Ready).To(BeFalse())
+			Expect(result.Objects()).To(BeEmpty())
+
+			By("not creating the dependent")
+			err = c.Get(context.TODO(), client.ObjectKeyFromObject(dependent.Build()), &appsv1.Deployment{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+			By("creating the dependent once the dependency exists")
+			Expect(c.Create(context.TODO(), configMap)).To(Succeed())
+
+			_, err = rec.ReconcileBuilders(context.TODO(), createOwner(), builders)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(dependent.Build()), &appsv1.Deployment{})).To(Succeed())
+		})
+
+		It("returns an error on dependency cycles", func() {
+			other := fake.NewDeploymentBuilder(fmt.Sprintf("deploy-%d", rand.Int31()), "default") //nolint:gosec
+			other.DependsOn = []resource.Dependency{{Object: &appsv1.Deployment{}, Name: dependent.Name, Namespace: "default"}}
+			dependent.DependsOn = []resource.Dependency{{Object: &appsv1.Deployment{}, Name: other.Name, Namespace: "default"}}
+
+			_, err := rec.ReconcileBuilders(context.TODO(), createOwner(), []resource.Builder{dependent, other})
+
+			var cycleErr *reconciler.DependencyCycleError
+			Expect(errors.As(err, &cycleErr)).To(BeTrue())
+			Expect(cycleErr.Resources).To(HaveLen(2))
+		})
+	})
+})
+
+func createOwner() *appsv1.Deployment {
+	ownerBuilder := fake.NewDeploymentBuilder(fmt.Sprintf("owner-%d", rand.Int31()), "default") //nolint:gosec
+	owner := ownerBuilder.Build().(*appsv1.Deployment)
+	Expect(ownerBuilder.Update(owner)).To(Succeed())
+	Expect(c.Create(context.TODO(), owner)).To(Succeed())
+	return owner
+}
+
+// cancelingBuilder is a DeploymentBuilder cancelling a context when updating its object.
+// failingClient is a client failing to get and delete objects of the provided name.
+type failingClient struct {
+	client.Client
+	name string
+}
+
+func (c *failingClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	if key.Name == c.name {
+		return apierrors.NewInternalError(errors.New("can't get object"))
+	}
+	return c.Client.Get(ctx, key, obj, opts...)
+}
+
+func (c *failingClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	if obj.GetName() == c.name {
+		return apierrors.NewInternalError(errors.New("can't delete object"))
+	}
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
+type cancelingBuilder struct {
+	*fake.DeploymentBuilder
+	cancel context.CancelFunc
+}
+
+func (b *cancelingBuilder) Update(object client.Object) error {
+	b.cancel()
+	return b.DeploymentBuilder.Update(object)
+}
+
+// mismatchingBuilder is a DeploymentBuilder building ConfigMaps.
+type mismatchingBuilder struct {
+	*fake.DeploymentBuilder
+}
+
+func (b *mismatchingBuilder) Build() client.Object {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      b.Name,
+			Namespace: b.Namespace,
+		},
+	}
+}
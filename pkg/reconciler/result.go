@@ -0,0 +1,114 @@
+// Licensed to Alexandre VILAIN under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Alexandre VILAIN licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package reconciler
+
+import (
+	"time"
+
+	"github.com/alexandrevilain/controller-tools/pkg/resource"
+	"gomodules.xyz/jsonpatch/v2"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// OperationResultDeleted means that the resource was deleted.
+	OperationResultDeleted controllerutil.OperationResult = "deleted"
+	// OperationResultSkipped means that the resource was skipped because its kind is not supported by the api server.
+	OperationResultSkipped controllerutil.OperationResult = "skipped"
+	// OperationResultWaiting means that the resource was not created because its dependencies are not ready yet.
+	OperationResultWaiting controllerutil.OperationResult = "waiting"
+)
+
+// DefaultRequeueAfter is the requeue duration suggested when resources are not ready.
+const DefaultRequeueAfter = 10 * time.Second
+
+// ResourceResult is the result of the reconciliation of a builder's resource.
+type ResourceResult struct {
+	// Object is the reconciled object.
+	Object client.Object
+	// GVK is the object's GroupVersionKind.
+	GVK schema.GroupVersionKind
+	// OperationResult is the operation performed on the object.
+	// controllerutil.OperationResultNone means that the object was unchanged.
+	OperationResult controllerutil.OperationResult
+	// Status is the object's status, computed using kstatus.
+	// It's only set for created, updated and unchanged objects.
+	// Objects created in dry-run mode have a NotFound status.
+	Status *resource.Status
+	// Diff is an unified diff between the live and desired YAML representations of the object.
+	// It's only set in dry-run mode, for created, updated and deleted objects.
+	Diff string
+	// Patch is the JSON patch turning the live object into the desired one.
+	// It's only set in dry-run mode, for created, updated and deleted objects.
+	Patch []jsonpatch.Operation
+	// Error is the error which occurred while reconciling the object.
+	// It's only set using ErrorPolicyBestEffort.
+	Error error
+}
+
+// Result is the result of the reconciliation of builders.
+type Result struct {
+	// Resources contains the result of every builder's resource, in reconciliation order.
+	Resources []ResourceResult
+	// Pruned contains the result of resources deleted because they're no longer produced by any builder.
+	Pruned []ResourceResult
+	// Ready is true when all created, updated and unchanged objects are ready,
+	// no resource is waiting for its dependencies and no resource failed.
+	Ready bool
+	// RequeueAfter is the suggested duration after which the owner should be reconciled again.
+	// It's zero when all resources are ready.
+	RequeueAfter time.Duration
+}
+
+func newResult(resources, pruned []ResourceResult) *Result {
+	result := &Result{
+		Resources: resources,
+		Pruned:    pruned,
+	}
+
+	result.Ready = len(result.NotReady()) == 0
+	if !result.Ready {
+		result.RequeueAfter = DefaultRequeueAfter
+	}
+
+	return result
+}
+
+// Objects returns objects of builders that are enabled and supported by the api server.
+func (r *Result) Objects() []client.Object {
+	objects := []client.Object{}
+	for _, res := range r.Resources {
+		if res.Status != nil {
+			objects = append(objects, res.Object)
+		}
+	}
+	return objects
+}
+
+// NotReady returns results of resources which are not ready yet.
+func (r *Result) NotReady() []ResourceResult {
+	results := []ResourceResult{}
+	for _, res := range r.Resources {
+		if res.Error != nil || res.OperationResult == OperationResultWaiting || (res.Status != nil && !res.Status.Ready) {
+			results = append(results, res)
+		}
+	}
+	return results
+}
@@ -0,0 +1,74 @@
+// Licensed to Alexandre VILAIN under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Alexandre VILAIN licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package reconciler
+
+import (
+	"testing"
+
+	"github.com/alexandrevilain/controller-tools/pkg/resource"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+func TestNewResult(t *testing.T) {
+	tests := map[string]struct {
+		resources            []ResourceResult
+		expectedReady        bool
+		expectedRequeueAfter bool
+		expectedObjects      int
+	}{
+		"all ready": {
+			resources: []ResourceResult{
+				{Object: &corev1.ConfigMap{}, OperationResult: controllerutil.OperationResultCreated, Status: &resource.Status{Ready: true}},
+				{Object: &corev1.ConfigMap{}, OperationResult: OperationResultDeleted},
+				{Object: &corev1.ConfigMap{}, OperationResult: OperationResultSkipped},
+			},
+			expectedReady:   true,
+			expectedObjects: 1,
+		},
+		"one not ready": {
+			resources: []ResourceResult{
+				{Object: &corev1.ConfigMap{}, OperationResult: controllerutil.OperationResultNone, Status: &resource.Status{Ready: true}},
+				{Object: &corev1.ConfigMap{}, OperationResult: controllerutil.OperationResultUpdated, Status: &resource.Status{Ready: false}},
+			},
+			expectedReady:        false,
+			expectedRequeueAfter: true,
+			expectedObjects:      2,
+		},
+		"one waiting for dependencies": {
+			resources: []ResourceResult{
+				{Object: &corev1.ConfigMap{}, OperationResult: controllerutil.OperationResultNone, Status: &resource.Status{Ready: true}},
+				{Object: &corev1.ConfigMap{}, OperationResult: OperationResultWaiting},
+			},
+			expectedReady:        false,
+			expectedRequeueAfter: true,
+			expectedObjects:      1,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(tt *testing.T) {
+			result := newResult(test.resources, nil)
+
+			assert.Equal(tt, test.expectedReady, result.Ready)
+			assert.Equal(tt, test.expectedRequeueAfter, result.RequeueAfter > 0)
+			assert.Len(tt, result.Objects(), test.expectedObjects)
+		})
+	}
+}
@@ -19,6 +19,7 @@ package resource
 
 import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	kstatus "sigs.k8s.io/cli-utils/pkg/kstatus/status"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -34,21 +35,76 @@ type Builder interface {
 	Update(client.Object) error
 }
 
-// A Comparer provides a custom function to compare two resources returned
-// by a Builder.
+// A Dependent is a Builder whose resource depends on other resources.
+// Dependencies are reconciled before the dependent resource and deleted after it.
+type Dependent interface {
+	// Dependencies returns the resources the builder's resource depends on.
+	Dependencies() []Dependency
+}
+
+// A FieldIgnorer is a Builder whose resource has fields managed outside of the builder,
+// like replicas managed by an HorizontalPodAutoscaler.
+// Ignored fields keep their live value when the resource is updated (see IgnoreFields for the path syntax).
+type FieldIgnorer interface {
+	// IgnoredFields returns the paths of the ignored fields.
+	IgnoredFields() []string
+}
+
+// A Comparer provides a custom function to compare the current state of a resource
+// with its desired state, computed by its Builder.
+// It's only used for the builder's own resource.
 type Comparer interface {
-	Equal()
+	// Equal returns true when current and desired are equal, meaning that the resource doesn't need to be updated.
+	Equal(current, desired client.Object) bool
 }
 
+// Status is the status of a kubernetes resource, computed using kstatus.
 type Status struct {
 	GVK       schema.GroupVersionKind
 	Name      string
 	Namespace string
 	Labels    map[string]string
-	Ready     bool
+	// Ready is true when the resource is fully reconciled (kstatus' Current status).
+	Ready bool
+	// State is the kstatus computed status of the resource.
+	State kstatus.Status
+	// Message is a human readable message describing the resource status.
+	Message string
+	// Conditions are the abnormal-true conditions (Reconciling, Stalled) generated by kstatus.
+	Conditions []kstatus.Condition
+	// Generation is the resource's metadata.generation.
+	Generation int64
+	// ObservedGeneration is the resource's status.observedGeneration, if the resource reports one.
+	ObservedGeneration *int64
+}
+
+// IsStale returns true when the resource's controller hasn't observed its latest generation yet.
+func (s *Status) IsStale() bool {
+	return s.ObservedGeneration != nil && *s.ObservedGeneration < s.Generation
+}
+
+// IsInProgress returns true when the resource is still being reconciled by its controller.
+func (s *Status) IsInProgress() bool {
+	return s.State == kstatus.InProgressStatus
+}
+
+// IsFailed returns true when the resource's controller reported an error while reconciling it.
+func (s *Status) IsFailed() bool {
+	return s.State == kstatus.FailedStatus
+}
+
+// IsTerminating returns true when the resource is being deleted.
+func (s *Status) IsTerminating() bool {
+	return s.State == kstatus.TerminatingStatus
+}
+
+// IsNotFound returns true when the resource doesn't exist.
+func (s *Status) IsNotFound() bool {
+	return s.State == kstatus.NotFoundStatus
 }
 
 // A Dependency is a reference to an object using its name, namespace and its object kind.
+// Object is only used to determine the dependency kind, Namespace must be empty for cluster-scoped objects.
 type Dependency struct {
 	Object    client.Object
 	Name      string
@@ -0,0 +1,420 @@
+// Licensed to Alexandre VILAIN under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Alexandre VILAIN licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package resource
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// podSpecDefaultedFields are the pod spec fields defaulted by the api server, relative to the pod spec.
+var podSpecDefaultedFields = []string{
+	"restartPolicy",
+	"terminationGracePeriodSeconds",
+	"dnsPolicy",
+	"securityContext",
+	"schedulerName",
+	"serviceAccount",
+	"enableServiceLinks",
+	"containers[*].terminationMessagePath",
+	"containers[*].terminationMessagePolicy",
+	"containers[*].imagePullPolicy",
+	"containers[*].ports[*].protocol",
+	"containers[*].livenessProbe.timeoutSeconds",
+	"containers[*].livenessProbe.periodSeconds",
+	"containers[*].livenessProbe.successThreshold",
+	"containers[*].livenessProbe.failureThreshold",
+	"containers[*].readinessProbe.timeoutSeconds",
+	"containers[*].readinessProbe.periodSeconds",
+	"containers[*].readinessProbe.successThreshold",
+	"containers[*].readinessProbe.failureThreshold",
+	"containers[*].startupProbe.timeoutSeconds",
+	"containers[*].startupProbe.periodSeconds",
+	"containers[*].startupProbe.successThreshold",
+	"containers[*].startupProbe.failureThreshold",
+	"initContainers[*].terminationMessagePath",
+	"initContainers[*].terminationMessagePolicy",
+	"initContainers[*].imagePullPolicy",
+	"volumes[*].configMap.defaultMode",
+	"volumes[*].secret.defaultMode",
+	"volumes[*].projected.defaultMode",
+	"volumes[*].downwardAPI.defaultMode",
+}
+
+// withPrefix returns the provided paths prefixed by prefix.
+func withPrefix(prefix string, paths []string) []string {
+	result := make([]string, 0, len(paths))
+	for _, path := range paths {
+		result = append(result, prefix+"."+path)
+	}
+	return result
+}
+
+// ServerDefaultedFields contains, by kind, the paths of the fields defaulted by the api server
+// for core workloads. When left unset by a builder, those fields keep their live value
+// instead of triggering an update on every reconciliation.
+var ServerDefaultedFields = map[schema.GroupKind][]string{
+	appsv1.SchemeGroupVersion.WithKind("Deployment").GroupKind(): append([]string{
+		"spec.replicas",
+		"spec.strategy",
+		"spec.revisionHistoryLimit",
+		"spec.progressDeadlineSeconds",
+	}, withPrefix("spec.template.spec", podSpecDefaultedFields)...),
+	appsv1.SchemeGroupVersion.WithKind("StatefulSet").GroupKind(): append([]string{
+		"spec.replicas",
+		"spec.podManagementPolicy",
+		"spec.updateStrategy",
+		"spec.revisionHistoryLimit",
+		"spec.persistentVolumeClaimRetentionPolicy",
+	}, withPrefix("spec.template.spec", podSpecDefaultedFields)...),
+	appsv1.SchemeGroupVersion.WithKind("DaemonSet").GroupKind(): append([]string{
+		"spec.updateStrategy",
+		"spec.revisionHistoryLimit",
+	}, withPrefix("spec.template.spec", podSpecDefaultedFields)...),
+	batchv1.SchemeGroupVersion.WithKind("Job").GroupKind(): append([]string{
+		"spec.parallelism",
+		"spec.completions",
+		"spec.backoffLimit",
+		"spec.completionMode",
+		"spec.suspend",
+		"spec.podReplacementPolicy",
+		"spec.selector",
+	}, withPrefix("spec.template.spec", podSpecDefaultedFields)...),
+	batchv1.SchemeGroupVersion.WithKind("CronJob").GroupKind(): append([]string{
+		"spec.concurrencyPolicy",
+		"spec.suspend",
+		"spec.successfulJobsHistoryLimit",
+		"spec.failedJobsHistoryLimit",
+	}, withPrefix("spec.jobTemplate.spec.template.spec", podSpecDefaultedFields)...),
+	corev1.SchemeGroupVersion.WithKind("Service").GroupKind(): {
+		"spec.type",
+		"spec.clusterIP",
+		"spec.clusterIPs",
+		"spec.sessionAffinity",
+		"spec.ipFamilies",
+		"spec.ipFamilyPolicy",
+		"spec.internalTrafficPolicy",
+		"spec.externalTrafficPolicy",
+		"spec.allocateLoadBalancerNodePorts",
+		"spec.ports[*].protocol",
+		"spec.ports[*].targetPort",
+		"spec.ports[*].nodePort",
+	},
+	corev1.SchemeGroupVersion.WithKind("Secret").GroupKind(): {
+		"type",
+	},
+	autoscalingv2.SchemeGroupVersion.WithKind("HorizontalPodAutoscaler").GroupKind(): {
+		"spec.minReplicas",
+		"spec.metrics",
+	},
+}
+
+// IgnoreFields sets the fields of desired matching the provided paths to their value in current.
+// Fields missing in current are removed from desired.
+//
+// Paths are dot separated field names, like "spec.replicas". List items are selected using
+// their index, like "spec.template.spec.containers[0].image", or all at once using "[*]".
+// Items of lists of objects having a name are matched by name, others are matched by index.
+// Paths not found in both objects are ignored.
+func IgnoreFields(current, desired client.Object, paths ...string) error {
+	return preserveFields(current, desired, paths, false)
+}
+
+// PreserveUnsetFields sets the fields of desired matching the provided paths, and unset in desired,
+// to their value in current. Fields set to empty objects are considered unset, as well as fields of typed objects
+// set to their zero value when their type can't represent an unset value, like intstr.IntOrString.
+// It's useful to keep values defaulted by the api server.
+// See IgnoreFields for the path syntax.
+func PreserveUnsetFields(current, desired client.Object, paths ...string) error {
+	return preserveFields(current, desired, paths, true)
+}
+
+func preserveFields(current, desired client.Object, paths []string, onlyUnset bool) error {
+	if len(paths) == 0 {
+		return nil
+	}
+
+	currentContent, err := runtime.DefaultUnstructuredConverter.ToUnstructured(current)
+	if err != nil {
+		return err
+	}
+
+	desiredContent, err := runtime.DefaultUnstructuredConverter.ToUnstructured(desired)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		segments, err := parseFieldPath(path)
+		if err != nil {
+			return err
+		}
+
+		var zero interface{}
+		if onlyUnset {
+			zero, err = zeroValue(desired, segments)
+			if err != nil {
+				return err
+			}
+		}
+
+		preserveField(currentContent, desiredContent, segments, onlyUnset, zero)
+	}
+
+	if u, ok := desired.(runtime.Unstructured); ok {
+		u.SetUnstructuredContent(desiredContent)
+		return nil
+	}
+
+	return runtime.DefaultUnstructuredConverter.FromUnstructured(desiredContent, desired)
+}
+
+// fieldPathSegment is a segment of a field path.
+type fieldPathSegment struct {
+	field string
+	// list is true when the segment selects list items.
+	list bool
+	// index is the selected list item, -1 selects all items.
+	index int
+}
+
+// parseFieldPath parses the provided field path into its segments.
+func parseFieldPath(path string) ([]fieldPathSegment, error) {
+	segments := []fieldPathSegment{}
+	for _, part := range strings.Split(path, ".") {
+		segment := fieldPathSegment{field: part}
+
+		if start := strings.Index(part, "["); start >= 0 {
+			if !strings.HasSuffix(part, "]") {
+				return nil, fmt.Errorf("invalid field path %q: unterminated list selector", path)
+			}
+
+			segment.field = part[:start]
+			segment.list = true
+			segment.index = -1
+
+			selector := part[start+1 : len(part)-1]
+			if selector != "*" {
+				index, err := strconv.Atoi(selector)
+				if err != nil || index < 0 {
+					return nil, fmt.Errorf("invalid field path %q: invalid list selector %q", path, selector)
+				}
+				segment.index = index
+			}
+		}
+
+		if segment.field == "" {
+			return nil, fmt.Errorf("invalid field path %q: empty field name", path)
+		}
+
+		segments = append(segments, segment)
+	}
+
+	return segments, nil
+}
+
+// preserveField copies the value of the field located by the provided segments from current to desired.
+// When onlyUnset is true, the value is only copied if the desired value is unset or equal to zero, if not nil.
+func preserveField(current, desired map[string]interface{}, segments []fieldPathSegment, onlyUnset bool, zero interface{}) {
+	segment := segments[0]
+	last := len(segments) == 1
+
+	if !segment.list {
+		if last {
+			copyValue(current, desired, segment.field, onlyUnset, zero)
+			return
+		}
+
+		currentChild, currentOk := current[segment.field].(map[string]interface{})
+		desiredChild, desiredOk := desired[segment.field].(map[string]interface{})
+		if currentOk && desiredOk {
+			preserveField(currentChild, desiredChild, segments[1:], onlyUnset, zero)
+		}
+		return
+	}
+
+	currentList, currentOk := current[segment.field].([]interface{})
+	desiredList, desiredOk := desired[segment.field].([]interface{})
+	if !currentOk || !desiredOk {
+		return
+	}
+
+	for i, desiredItem := range desiredList {
+		if segment.index >= 0 && segment.index != i {
+			continue
+		}
+
+		currentItem, found := matchListItem(currentList, desiredItem, i)
+		if !found {
+			continue
+		}
+
+		if last {
+			if !onlyUnset {
+				desiredList[i] = runtime.DeepCopyJSONValue(currentItem)
+			}
+			continue
+		}
+
+		currentChild, currentOk := currentItem.(map[string]interface{})
+		desiredChild, desiredOk := desiredItem.(map[string]interface{})
+		if currentOk && desiredOk {
+			preserveField(currentChild, desiredChild, segments[1:], onlyUnset, zero)
+		}
+	}
+}
+
+// copyValue copies the value of the provided field from current to desired.
+func copyValue(current, desired map[string]interface{}, field string, onlyUnset bool, zero interface{}) {
+	if onlyUnset && !isUnset(desired[field], zero) {
+		return
+	}
+
+	value, found := current[field]
+	if !found {
+		if !onlyUnset {
+			delete(desired, field)
+		}
+		return
+	}
+
+	desired[field] = runtime.DeepCopyJSONValue(value)
+}
+
+// isUnset returns true for missing values and empty objects,
+// as typed objects without pointers are converted to empty objects.
+// Values equal to the provided zero value, if not nil, are also unset.
+func isUnset(value, zero interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case map[string]interface{}:
+		return len(v) == 0
+	default:
+		return zero != nil && reflect.DeepEqual(value, zero)
+	}
+}
+
+// zeroValue returns the unstructured representation of the zero value of the field of the provided typed object
+// located by the provided segments, if the field's type can't represent an unset value.
+// Such fields, like intstr.IntOrString ones, are converted to their zero value when left unset.
+// It returns nil for pointers, maps, slices, unknown fields and unstructured objects, which can be unset.
+func zeroValue(object interface{}, segments []fieldPathSegment) (interface{}, error) {
+	t := reflect.TypeOf(object)
+	for _, segment := range segments {
+		field, found := jsonField(t, segment.field)
+		if !found {
+			return nil, nil
+		}
+
+		t = field.Type
+		if segment.list {
+			if t.Kind() != reflect.Slice {
+				return nil, nil
+			}
+			t = t.Elem()
+		}
+	}
+
+	switch t.Kind() { //nolint:exhaustive
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		return nil, nil
+	}
+
+	data, err := json.Marshal(reflect.Zero(t).Interface())
+	if err != nil {
+		return nil, fmt.Errorf("can't marshal %s zero value: %w", t, err)
+	}
+
+	var zero interface{}
+	err = utiljson.Unmarshal(data, &zero)
+	if err != nil {
+		return nil, fmt.Errorf("can't unmarshal %s zero value: %w", t, err)
+	}
+
+	return zero, nil
+}
+
+// jsonField returns the field of the provided struct type serialized with the provided name,
+// looking into inlined embedded structs.
+func jsonField(t reflect.Type, name string) (reflect.StructField, bool) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return reflect.StructField{}, false
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := strings.Split(field.Tag.Get("json"), ",")
+		if tag[0] == name {
+			return field, true
+		}
+
+		if field.Anonymous && tag[0] == "" {
+			if embedded, found := jsonField(field.Type, name); found {
+				return embedded, true
+			}
+		}
+	}
+
+	return reflect.StructField{}, false
+}
+
+// matchListItem returns the item of the current list matching the provided desired item.
+// Items with a name are matched by name, others by index.
+func matchListItem(currentList []interface{}, desiredItem interface{}, index int) (interface{}, bool) {
+	if name, ok := itemName(desiredItem); ok {
+		for _, currentItem := range currentList {
+			if currentName, ok := itemName(currentItem); ok && currentName == name {
+				return currentItem, true
+			}
+		}
+		return nil, false
+	}
+
+	if index < len(currentList) {
+		return currentList[index], true
+	}
+	return nil, false
+}
+
+// itemName returns the name of the provided list item, if any.
+func itemName(item interface{}) (string, bool) {
+	object, ok := item.(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+
+	name, ok := object["name"].(string)
+	return name, ok && name != ""
+}
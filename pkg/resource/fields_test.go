@@ -0,0 +1,224 @@
+// Licensed to Alexandre VILAIN under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Alexandre VILAIN licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package resource_test
+
+import (
+	"testing"
+
+	"github.com/alexandrevilain/controller-tools/pkg/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func newDeployment(replicas int32, containers ...corev1.Container) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: ptr.To(replicas),
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: containers,
+				},
+			},
+		},
+	}
+}
+
+func TestIgnoreFields(t *testing.T) {
+	tests := map[string]struct {
+		current  *appsv1.Deployment
+		desired  *appsv1.Deployment
+		paths    []string
+		expected *appsv1.Deployment
+		err      string
+	}{
+		"keeps the live value": {
+			current:  newDeployment(5),
+			desired:  newDeployment(1),
+			paths:    []string{"spec.replicas"},
+			expected: newDeployment(5),
+		},
+		"removes fields missing in current": {
+			current: &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"}},
+			desired: newDeployment(1),
+			paths:   []string{"spec.replicas"},
+			expected: func() *appsv1.Deployment {
+				d := newDeployment(1)
+				d.Spec.Replicas = nil
+				return d
+			}(),
+		},
+		"matches all list items by name": {
+			current: newDeployment(1,
+				corev1.Container{Name: "b", Image: "b:live"},
+				corev1.Container{Name: "a", Image: "a:live"},
+			),
+			desired: newDeployment(1,
+				corev1.Container{Name: "a", Image: "a:desired"},
+				corev1.Container{Name: "b", Image: "b:desired"},
+				corev1.Container{Name: "c", Image: "c:desired"},
+			),
+			paths: []string{"spec.template.spec.containers[*].image"},
+			expected: newDeployment(1,
+				corev1.Container{Name: "a", Image: "a:live"},
+				corev1.Container{Name: "b", Image: "b:live"},
+				corev1.Container{Name: "c", Image: "c:desired"},
+			),
+		},
+		"selects list items by index": {
+			current: newDeployment(1,
+				corev1.Container{Name: "a", Image: "a:live"},
+				corev1.Container{Name: "b", Image: "b:live"},
+			),
+			desired: newDeployment(1,
+				corev1.Container{Name: "a", Image: "a:desired"},
+				corev1.Container{Name: "b", Image: "b:desired"},
+			),
+			paths: []string{"spec.template.spec.containers[1].image"},
+			expected: newDeployment(1,
+				corev1.Container{Name: "a", Image: "a:desired"},
+				corev1.Container{Name: "b", Image: "b:live"},
+			),
+		},
+		"ignores paths missing in desired": {
+			current:  newDeployment(1, corev1.Container{Name: "a", ReadinessProbe: &corev1.Probe{TimeoutSeconds: 1}}),
+			desired:  newDeployment(1, corev1.Container{Name: "a"}),
+			paths:    []string{"spec.template.spec.containers[*].readinessProbe.timeoutSeconds"},
+			expected: newDeployment(1, corev1.Container{Name: "a"}),
+		},
+		"returns an error on invalid paths": {
+			current: newDeployment(1),
+			desired: newDeployment(1),
+			paths:   []string{"spec.template.spec.containers[a].image"},
+			err:     `invalid field path "spec.template.spec.containers[a].image": invalid list selector "a"`,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(tt *testing.T) {
+			err := resource.IgnoreFields(test.current, test.desired, test.paths...)
+			if test.err != "" {
+				assert.EqualError(tt, err, test.err)
+				return
+			}
+			require.NoError(tt, err)
+			assert.Equal(tt, test.expected, test.desired)
+		})
+	}
+}
+
+func TestPreserveUnsetFields(t *testing.T) {
+	current := newDeployment(3, corev1.Container{
+		Name:                     "a",
+		Image:                    "a",
+		ImagePullPolicy:          corev1.PullIfNotPresent,
+		TerminationMessagePath:   corev1.TerminationMessagePathDefault,
+		TerminationMessagePolicy: corev1.TerminationMessageReadFile,
+	})
+	current.Spec.RevisionHistoryLimit = ptr.To[int32](10)
+	current.Spec.Strategy = appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType}
+
+	desired := newDeployment(1, corev1.Container{
+		Name:            "a",
+		Image:           "a",
+		ImagePullPolicy: corev1.PullAlways,
+	})
+
+	gk := appsv1.SchemeGroupVersion.WithKind("Deployment").GroupKind()
+	err := resource.PreserveUnsetFields(current, desired, resource.ServerDefaultedFields[gk]...)
+	require.NoError(t, err)
+
+	expected := newDeployment(1, corev1.Container{
+		Name:                     "a",
+		Image:                    "a",
+		ImagePullPolicy:          corev1.PullAlways,
+		TerminationMessagePath:   corev1.TerminationMessagePathDefault,
+		TerminationMessagePolicy: corev1.TerminationMessageReadFile,
+	})
+	expected.Spec.RevisionHistoryLimit = ptr.To[int32](10)
+	expected.Spec.Strategy = appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType}
+
+	assert.Equal(t, expected, desired)
+}
+
+func TestPreserveUnsetFieldsZeroValues(t *testing.T) {
+	newService := func(targetPort intstr.IntOrString) *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+			Spec: corev1.ServiceSpec{
+				Ports: []corev1.ServicePort{{Name: "http", Port: 80, TargetPort: targetPort}},
+			},
+		}
+	}
+	serviceGK := corev1.SchemeGroupVersion.WithKind("Service").GroupKind()
+	deploymentGK := appsv1.SchemeGroupVersion.WithKind("Deployment").GroupKind()
+
+	tests := map[string]struct {
+		current  client.Object
+		desired  client.Object
+		paths    []string
+		expected client.Object
+	}{
+		"preserves zero values of types which can't be unset": {
+			current:  newService(intstr.FromInt32(80)),
+			desired:  newService(intstr.IntOrString{}),
+			paths:    resource.ServerDefaultedFields[serviceGK],
+			expected: newService(intstr.FromInt32(80)),
+		},
+		"keeps set values of types which can't be unset": {
+			current:  newService(intstr.FromInt32(80)),
+			desired:  newService(intstr.FromString("http")),
+			paths:    resource.ServerDefaultedFields[serviceGK],
+			expected: newService(intstr.FromString("http")),
+		},
+		"keeps zero values of pointers": {
+			current:  newDeployment(3),
+			desired:  newDeployment(0),
+			paths:    resource.ServerDefaultedFields[deploymentGK],
+			expected: newDeployment(0),
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(tt *testing.T) {
+			err := resource.PreserveUnsetFields(test.current, test.desired, test.paths...)
+			require.NoError(tt, err)
+			assert.Equal(tt, test.expected, test.desired)
+		})
+	}
+}
+
+func TestIgnoreFieldsUnstructured(t *testing.T) {
+	current := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{"replicas": int64(5)},
+	}}
+	desired := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{"replicas": int64(1)},
+	}}
+
+	require.NoError(t, resource.IgnoreFields(current, desired, "spec.replicas"))
+
+	replicas, _, err := unstructured.NestedInt64(desired.Object, "spec", "replicas")
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), replicas)
+}
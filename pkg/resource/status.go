@@ -20,11 +20,13 @@ package resource
 import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	kstatus "sigs.k8s.io/cli-utils/pkg/kstatus/status"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // GetStatus returns the provided object's status.
+// The object's GVK must be set to use kind-specific status rules.
 func GetStatus(res client.Object) (*Status, error) {
 	uobj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(res)
 	if err != nil {
@@ -34,16 +36,38 @@ func GetStatus(res client.Object) (*Status, error) {
 	u := &unstructured.Unstructured{}
 	u.SetUnstructuredContent(uobj)
 
-	status, err := kstatus.Compute(u)
+	result, err := kstatus.Compute(u)
 	if err != nil {
 		return nil, err
 	}
 
+	status := &Status{
+		GVK:        res.GetObjectKind().GroupVersionKind(),
+		Name:       res.GetName(),
+		Namespace:  res.GetNamespace(),
+		Labels:     res.GetLabels(),
+		Ready:      result.Status == kstatus.CurrentStatus,
+		State:      result.Status,
+		Message:    result.Message,
+		Conditions: result.Conditions,
+		Generation: res.GetGeneration(),
+	}
+
+	observedGeneration, found, err := unstructured.NestedInt64(uobj, "status", "observedGeneration")
+	if err == nil && found {
+		status.ObservedGeneration = &observedGeneration
+	}
+
+	return status, nil
+}
+
+// NewNotFoundStatus returns the status of a resource that doesn't exist.
+func NewNotFoundStatus(gvk schema.GroupVersionKind, name, namespace string) *Status {
 	return &Status{
-		GVK:       res.GetObjectKind().GroupVersionKind(),
-		Name:      res.GetName(),
-		Namespace: res.GetNamespace(),
-		Labels:    res.GetLabels(),
-		Ready:     status.Status == kstatus.CurrentStatus,
-	}, nil
+		GVK:       gvk,
+		Name:      name,
+		Namespace: namespace,
+		State:     kstatus.NotFoundStatus,
+		Message:   "Resource not found",
+	}
 }
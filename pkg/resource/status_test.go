@@ -0,0 +1,129 @@
+// Licensed to Alexandre VILAIN under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Alexandre VILAIN licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package resource_test
+
+import (
+	"testing"
+
+	"github.com/alexandrevilain/controller-tools/pkg/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kstatus "sigs.k8s.io/cli-utils/pkg/kstatus/status"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestGetStatus(t *testing.T) {
+	var replicas int32 = 1
+	now := metav1.Now()
+
+	tests := map[string]struct {
+		object           client.Object
+		expectedState    kstatus.Status
+		expectedReady    bool
+		expectedStale    bool
+		expectedMessage  string
+		expectConditions bool
+	}{
+		"ready deployment": {
+			object: &appsv1.Deployment{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Generation: 1},
+				Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+				Status: appsv1.DeploymentStatus{
+					ObservedGeneration: 1,
+					Replicas:           1,
+					UpdatedReplicas:    1,
+					ReadyReplicas:      1,
+					AvailableReplicas:  1,
+					Conditions: []appsv1.DeploymentCondition{
+						{Type: appsv1.DeploymentAvailable, Status: corev1.ConditionTrue},
+						{Type: appsv1.DeploymentProgressing, Status: corev1.ConditionTrue, Reason: "NewReplicaSetAvailable"},
+					},
+				},
+			},
+			expectedState:   kstatus.CurrentStatus,
+			expectedReady:   true,
+			expectedMessage: "Deployment is available. Replicas: 1",
+		},
+		"stale deployment": {
+			object: &appsv1.Deployment{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Generation: 2},
+				Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+				Status: appsv1.DeploymentStatus{
+					ObservedGeneration: 1,
+				},
+			},
+			expectedState:    kstatus.InProgressStatus,
+			expectedStale:    true,
+			expectedMessage:  "Deployment generation is 2, but latest observed generation is 1",
+			expectConditions: true,
+		},
+		"failed job": {
+			object: &batchv1.Job{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "batch/v1", Kind: "Job"},
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+				Status: batchv1.JobStatus{
+					Failed: 1,
+					Conditions: []batchv1.JobCondition{
+						{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Message: "Job has reached the specified backoff limit"},
+					},
+				},
+			},
+			expectedState:    kstatus.FailedStatus,
+			expectedMessage:  "Job Failed. failed: 1/1",
+			expectConditions: true,
+		},
+		"terminating configmap": {
+			object: &corev1.ConfigMap{
+				TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "test",
+					DeletionTimestamp: &now,
+				},
+			},
+			expectedState:   kstatus.TerminatingStatus,
+			expectedMessage: "Resource scheduled for deletion",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(tt *testing.T) {
+			status, err := resource.GetStatus(test.object)
+			require.NoError(tt, err)
+
+			assert.Equal(tt, test.expectedState, status.State)
+			assert.Equal(tt, test.expectedReady, status.Ready)
+			assert.Equal(tt, test.expectedStale, status.IsStale())
+			assert.Equal(tt, test.expectedMessage, status.Message)
+			assert.Equal(tt, test.expectConditions, len(status.Conditions) > 0)
+			assert.Equal(tt, test.object.GetObjectKind().GroupVersionKind(), status.GVK)
+		})
+	}
+}
+
+func TestNewNotFoundStatus(t *testing.T) {
+	status := resource.NewNotFoundStatus(corev1.SchemeGroupVersion.WithKind("ConfigMap"), "test", "default")
+
+	assert.True(t, status.IsNotFound())
+	assert.False(t, status.Ready)
+}
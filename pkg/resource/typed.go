@@ -0,0 +1,154 @@
+// Licensed to Alexandre VILAIN under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Alexandre VILAIN licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package resource
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// A TypedBuilder is a Builder working with a concrete object type, checked at compile time.
+// Use NewBuilder to get a Builder from a TypedBuilder.
+// A TypedBuilder can also implement Dependent, FieldIgnorer and either TypedComparer or Comparer.
+type TypedBuilder[T client.Object] interface {
+	// Build returns the initial object.
+	// Most of the time, it should only return object with its object metas
+	Build() T
+	// Enabled returns whenever the builder is enabled in the current context.
+	// It's a convenient method to know if the resource created by the builder should be created or deleted.
+	Enabled() bool
+	// Update updates the provided object to match builder's expected resource state.
+	Update(T) error
+}
+
+// A TypedComparer is a Comparer working with a concrete object type.
+type TypedComparer[T client.Object] interface {
+	// Equal returns true when current and desired are equal, meaning that the resource doesn't need to be updated.
+	Equal(current, desired T) bool
+}
+
+// TypeMismatchError is returned when a builder is called with an object of an unexpected type.
+type TypeMismatchError struct {
+	// Expected is the type expected by the builder.
+	Expected string
+	// Actual is the type of the provided object.
+	Actual string
+}
+
+func (e *TypeMismatchError) Error() string {
+	return fmt.Sprintf("unexpected object type: expected %s, got %s", e.Expected, e.Actual)
+}
+
+// Cast returns the provided object as a T.
+// It returns a TypeMismatchError instead of panicking when the object isn't a T.
+func Cast[T client.Object](object client.Object) (T, error) {
+	typed, ok := object.(T)
+	if !ok {
+		return typed, &TypeMismatchError{
+			Expected: fmt.Sprintf("%T", typed),
+			Actual:   fmt.Sprintf("%T", object),
+		}
+	}
+	return typed, nil
+}
+
+// NewBuilder returns a Builder calling the provided TypedBuilder.
+// The returned builder forwards the Dependent, FieldIgnorer, TypedComparer and Comparer
+// interfaces implemented by the typed builder.
+func NewBuilder[T client.Object](builder TypedBuilder[T]) Builder {
+	adapter := &typedBuilder[T]{builder: builder}
+
+	switch comparer := any(builder).(type) {
+	case TypedComparer[T]:
+		return &typedComparingBuilder[T]{
+			typedBuilder: adapter,
+			equal: func(current, desired client.Object) bool {
+				typedCurrent, err := Cast[T](current)
+				if err != nil {
+					return false
+				}
+				typedDesired, err := Cast[T](desired)
+				if err != nil {
+					return false
+				}
+				return comparer.Equal(typedCurrent, typedDesired)
+			},
+		}
+	case Comparer:
+		return &typedComparingBuilder[T]{
+			typedBuilder: adapter,
+			equal:        comparer.Equal,
+		}
+	}
+
+	return adapter
+}
+
+// typedBuilder adapts a TypedBuilder to the Builder interface.
+type typedBuilder[T client.Object] struct {
+	builder TypedBuilder[T]
+}
+
+var (
+	_ Builder      = &typedBuilder[client.Object]{}
+	_ Dependent    = &typedBuilder[client.Object]{}
+	_ FieldIgnorer = &typedBuilder[client.Object]{}
+)
+
+func (b *typedBuilder[T]) Build() client.Object {
+	return b.builder.Build()
+}
+
+func (b *typedBuilder[T]) Enabled() bool {
+	return b.builder.Enabled()
+}
+
+func (b *typedBuilder[T]) Update(object client.Object) error {
+	typed, err := Cast[T](object)
+	if err != nil {
+		return err
+	}
+	return b.builder.Update(typed)
+}
+
+func (b *typedBuilder[T]) Dependencies() []Dependency {
+	if dependent, ok := b.builder.(Dependent); ok {
+		return dependent.Dependencies()
+	}
+	return nil
+}
+
+func (b *typedBuilder[T]) IgnoredFields() []string {
+	if ignorer, ok := b.builder.(FieldIgnorer); ok {
+		return ignorer.IgnoredFields()
+	}
+	return nil
+}
+
+// typedComparingBuilder is a typedBuilder whose typed builder provides a custom comparison function.
+type typedComparingBuilder[T client.Object] struct {
+	*typedBuilder[T]
+	equal func(current, desired client.Object) bool
+}
+
+var _ Comparer = &typedComparingBuilder[client.Object]{}
+
+func (b *typedComparingBuilder[T]) Equal(current, desired client.Object) bool {
+	return b.equal(current, desired)
+}
@@ -0,0 +1,126 @@
+// Licensed to Alexandre VILAIN under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Alexandre VILAIN licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package resource_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/alexandrevilain/controller-tools/pkg/fake"
+	"github.com/alexandrevilain/controller-tools/pkg/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type comparingConfigMapBuilder struct {
+	*fake.ConfigMapBuilder
+}
+
+func (b *comparingConfigMapBuilder) Equal(current, desired *corev1.ConfigMap) bool {
+	return current.Data["key"] == desired.Data["key"]
+}
+
+func TestNewBuilder(t *testing.T) {
+	typed := fake.NewConfigMapBuilder("test", "default")
+	typed.Data = map[string]string{"key": "value"}
+	typed.DependsOn = []resource.Dependency{{Object: &appsv1.Deployment{}, Name: "test", Namespace: "default"}}
+
+	builder := resource.NewBuilder[*corev1.ConfigMap](typed)
+	assert.True(t, builder.Enabled())
+
+	object := builder.Build()
+	require.IsType(t, &corev1.ConfigMap{}, object)
+	assert.Equal(t, "test", object.GetName())
+
+	require.NoError(t, builder.Update(object))
+	assert.Equal(t, typed.Data, object.(*corev1.ConfigMap).Data)
+
+	dependent, ok := builder.(resource.Dependent)
+	require.True(t, ok)
+	assert.Equal(t, typed.DependsOn, dependent.Dependencies())
+
+	_, ok = builder.(resource.Comparer)
+	assert.False(t, ok)
+}
+
+func TestNewBuilderTypeMismatch(t *testing.T) {
+	builder := resource.NewBuilder[*corev1.ConfigMap](fake.NewConfigMapBuilder("test", "default"))
+
+	err := builder.Update(&appsv1.Deployment{})
+	require.Error(t, err)
+
+	var mismatchErr *resource.TypeMismatchError
+	require.True(t, errors.As(err, &mismatchErr))
+	assert.Equal(t, "*v1.ConfigMap", mismatchErr.Expected)
+	assert.Equal(t, "*v1.Deployment", mismatchErr.Actual)
+}
+
+func TestNewBuilderComparer(t *testing.T) {
+	builder := resource.NewBuilder[*corev1.ConfigMap](&comparingConfigMapBuilder{fake.NewConfigMapBuilder("test", "default")})
+
+	comparer, ok := builder.(resource.Comparer)
+	require.True(t, ok)
+
+	newConfigMap := func(value string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Labels: map[string]string{"value": value}},
+			Data:       map[string]string{"key": value},
+		}
+	}
+
+	tests := map[string]struct {
+		current  client.Object
+		desired  client.Object
+		expected bool
+	}{
+		"equal": {
+			current:  newConfigMap("a"),
+			desired:  newConfigMap("a"),
+			expected: true,
+		},
+		"different": {
+			current:  newConfigMap("a"),
+			desired:  newConfigMap("b"),
+			expected: false,
+		},
+		"type mismatch": {
+			current:  &appsv1.Deployment{},
+			desired:  newConfigMap("a"),
+			expected: false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(tt *testing.T) {
+			assert.Equal(tt, test.expected, comparer.Equal(test.current, test.desired))
+		})
+	}
+}
+
+func TestCast(t *testing.T) {
+	deploy, err := resource.Cast[*appsv1.Deployment](&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "test"}})
+	require.NoError(t, err)
+	assert.Equal(t, "test", deploy.Name)
+
+	_, err = resource.Cast[*appsv1.Deployment](&corev1.ConfigMap{})
+	assert.EqualError(t, err, "unexpected object type: expected *v1.Deployment, got *v1.ConfigMap")
+}